@@ -3,12 +3,14 @@ package chess
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 type Context struct {
 	State               State
 	ColorsTurn          Color
 	WinningPlayer       *Player
+	Termination         Termination
 	whiteCanCastleRight bool
 	whiteCanCastleLeft  bool
 	blackCanCastleRight bool
@@ -19,10 +21,11 @@ type Context struct {
 }
 
 func (c Context) String() string {
-	return fmt.Sprintf("%s/%s/%s/%s/%v/%v/%v/%v/%s/%d/%d",
+	return fmt.Sprintf("%s/%s/%s/%s/%s/%v/%v/%v/%v/%s/%d/%d",
 		c.State,
 		c.ColorsTurn,
 		c.WinningPlayer,
+		c.Termination,
 		c.Score(),
 		c.whiteCanCastleRight,
 		c.whiteCanCastleLeft,
@@ -34,27 +37,161 @@ func (c Context) String() string {
 	)
 }
 
-func (c *Context) Score() string {
-	if c.WinningPlayer == nil {
-		return ""
+// Result of the game, NoResult while the game is still ongoing
+func (c *Context) Result() Result {
+	if c.WinningPlayer != nil {
+		switch c.WinningPlayer.Color {
+		case White:
+			return WhiteWon
+		case Black:
+			return BlackWon
+		}
 	}
-	switch c.WinningPlayer.Color {
-	case White:
+	if c.State == Draw {
+		return Drawn
+	}
+	return NoResult
+}
+
+func (c *Context) Score() string {
+	switch c.Result() {
+	case WhiteWon:
 		return "1 - 0"
-	case Black:
+	case BlackWon:
 		return "0 - 1"
-	}
-	switch c.State {
-	case Draw:
+	case Drawn:
 		return "1/2 - 1/2"
 	}
 	return ""
 }
 
+var resultToPGN = map[Result]string{
+	NoResult: "*",
+	WhiteWon: "1-0",
+	BlackWon: "0-1",
+	Drawn:    "1/2-1/2",
+}
+
+// String returns the result as the game termination marker of PGN
+func (r Result) String() string {
+	return resultToPGN[r]
+}
+
 type Board struct {
 	board [64]Piece
 }
 
+var (
+	ErrSquare = errors.New("no such square")
+	ErrPiece  = errors.New("no such piece")
+)
+
+// Value of the pieces in pawns, the king is not counted as material
+var pieceValue = map[Piece]int{
+	Pawn:   1,
+	Knight: 3,
+	Bishop: 3,
+	Rook:   5,
+	Queen:  9,
+}
+
+// NewBoard returns an empty board
+func NewBoard() *Board {
+	return &Board{}
+}
+
+// ParseSquare returns the square of a name, "e4"
+func ParseSquare(name string) (Square, error) {
+	s, found := stringToSquare[strings.ToLower(name)]
+	if !found {
+		return none, fmt.Errorf("%q: %w", name, ErrSquare)
+	}
+	return s, nil
+}
+
+// PieceAt returns the piece on the square, Empty if there is none
+func (b *Board) PieceAt(s Square) (Piece, error) {
+	if err := checkSquare(s); err != nil {
+		return Empty, err
+	}
+	return b.board[s], nil
+}
+
+// SetPiece puts the piece on the square, replacing any piece on it. The
+// piece has a color, WhitePawn but not Pawn, anything else is an ErrPiece,
+// and a square off the board an ErrSquare.
+func (b *Board) SetPiece(s Square, p Piece) error {
+	if err := checkSquare(s); err != nil {
+		return err
+	}
+	if p < BlackKing || p > WhiteKing || p == Empty {
+		return fmt.Errorf("%d: %w", p, ErrPiece)
+	}
+	b.board[s] = p
+	return nil
+}
+
+// RemovePiece empties the square, returning the piece that was on it
+func (b *Board) RemovePiece(s Square) (Piece, error) {
+	if err := checkSquare(s); err != nil {
+		return Empty, err
+	}
+	p := b.board[s]
+	b.board[s] = Empty
+	return p, nil
+}
+
+func checkSquare(s Square) error {
+	if s < a1 || s > h8 {
+		return fmt.Errorf("%d: %w", s, ErrSquare)
+	}
+	return nil
+}
+
+// Squares returns the squares holding the piece, from a1 to h8
+func (b *Board) Squares(p Piece) []Square {
+	return getPieceSquares(p, b.board)
+}
+
+// Pieces returns the pieces of the color by their square, Both gives all
+// pieces on the board
+func (b *Board) Pieces(c Color) map[Square]Piece {
+	pieces := map[Square]Piece{}
+	for s := a1; s <= h8; s++ {
+		p := b.board[s]
+		if (p > 0 && (c == White || c == Both)) || (p < 0 && (c == Black || c == Both)) {
+			pieces[s] = p
+		}
+	}
+	return pieces
+}
+
+// Material returns the value of the pieces of the color, counting a pawn as
+// 1, a knight or a bishop as 3, a rook as 5 and a queen as 9
+func (b *Board) Material(c Color) int {
+	var material int
+	for _, p := range b.Pieces(c) {
+		material += pieceValue[pieceKind(p)]
+	}
+	return material
+}
+
+// Mirror returns the board seen from the other side, the ranks reversed
+// and the colors of the pieces swapped
+func (b *Board) Mirror() *Board {
+	mirrored := &Board{}
+	for s := a1; s <= h8; s++ {
+		// Pieces of the two colors have opposite values
+		mirrored.board[(7-s.row())*8+s.col()] = -b.board[s]
+	}
+	return mirrored
+}
+
+// Equal reports if both boards have the same pieces on the same squares
+func (b *Board) Equal(other *Board) bool {
+	return b.board == other.board
+}
+
 var playerToFen = map[Color]string{
 	White: "w",
 	Black: "b",
@@ -91,37 +228,15 @@ func ValidMoves(b *Board, p Color, c Context) ([]string, error) {
 	moves := validMovesForPlayer(p, b.board, c)
 	var strMoves []string
 	for _, move := range moves {
-		strMoves = append(strMoves, move.FromSquare.String()+move.ToSquare.String())
+		strMoves = append(strMoves, move.UCI())
 	}
 	return strMoves, nil
 }
 
-func isDraw(player Color, board [64]Piece, ctx Context) bool {
-	moves := validMovesForPlayer(player, board, ctx)
-	if moves == nil {
-		return true
-	}
-	if twoKings(board) {
-		return true
-	}
-	return false
-}
-
-func twoKings(b [64]Piece) bool {
-	for i := a1; i <= h8; i++ {
-		switch b[i] {
-		case WhitePawn, WhiteBishop, WhiteKnight, WhiteRook, WhiteQueen, BlackPawn, BlackBishop, BlackKnight, BlackRook, BlackQueen:
-			return false
-		}
-	}
-	return true
-}
-
 func makeMove(m Move, b [64]Piece) [64]Piece {
 	for _, pp := range m.piecePositions {
 		b[pp.position] = pp.piece
 	}
-	m.timeStamp = timeNow()
 	return b
 }
 
@@ -143,12 +258,7 @@ func inCheck(kingSquare Square, board [64]Piece) bool {
 		opponent = White
 	}
 
-	for _, oppPiece := range getPieces(opponent, board) {
-		if inSquares(kingSquare, getSquares(getTargets(oppPiece, board))) {
-			return true
-		}
-	}
-	return false
+	return isAttacked(kingSquare, opponent, board)
 }
 
 //CheckMove if piece on square s check mates player p
@@ -172,15 +282,15 @@ func isCheckMated(kingSquare Square, board [64]Piece) bool {
 	var toBlock []Square
 	var targets []Move
 
-	//Try to escape with king
+	//Try to escape with king, moves are tried on a copy of the board
 	var tmpKingSquare Square
+	var tmpBoard [64]Piece
 	for _, move := range kingMoves(kingSquare, board) {
-		board = makeMove(move, board)
-		tmpKingSquare = getKingSquareMust(hero, board)
-		if !inCheck(tmpKingSquare, board) {
+		tmpBoard = makeMove(move, board)
+		tmpKingSquare = getKingSquareMust(hero, tmpBoard)
+		if !inCheck(tmpKingSquare, tmpBoard) {
 			return false
 		}
-		board = makeMove(*move.reverseMove, board)
 	}
 
 	//get all attacks from opponent to block
@@ -198,11 +308,10 @@ func isCheckMated(kingSquare Square, board [64]Piece) bool {
 	for _, source := range squaresWithoutKing(hero, board) {
 		for _, target := range validMovesForSquare(source, board, Context{}) {
 			if inSquares(target.ToSquare, toBlock) {
-				board = makeMove(target, board)
-				if !inCheck(kingSquare, board) {
+				tmpBoard = makeMove(target, board)
+				if !inCheck(kingSquare, tmpBoard) {
 					return false
 				}
-				board = makeMove(*target.reverseMove, board)
 			}
 		}
 	}
@@ -232,19 +341,29 @@ func getPieces(p Color, board [64]Piece) []Square {
 	return pieces
 }
 
-func (b *Board) getSquare(s string) (Square, Square, error) {
-	if len(s) != 4 {
-		return none, none, errors.New("wrong length")
+// getSquare parses a move in coordinate form, "e2e4", with an optional
+// promotion piece, "e7e8q". The promotion piece is returned without color,
+// and is Empty if not given
+func (b *Board) getSquare(s string) (Square, Square, Piece, error) {
+	if len(s) != 4 && len(s) != 5 {
+		return none, none, Empty, errors.New("wrong length")
 	}
 	sq1, found := stringToSquare[s[:2]]
 	if !found {
-		return none, none, fmt.Errorf("no such move: %s", s)
+		return none, none, Empty, fmt.Errorf("no such move: %s", s)
 	}
-	sq2, found := stringToSquare[s[2:]]
+	sq2, found := stringToSquare[s[2:4]]
 	if !found {
-		return none, none, fmt.Errorf("no such move: %s", s)
+		return none, none, Empty, fmt.Errorf("no such move: %s", s)
+	}
+	if len(s) == 4 {
+		return sq1, sq2, Empty, nil
+	}
+	promo, found := byteToPiece[strings.ToUpper(s[4:])[0]]
+	if !found || promo == King {
+		return none, none, Empty, fmt.Errorf("no such promotion: %s", s)
 	}
-	return sq1, sq2, nil
+	return sq1, sq2, promo, nil
 }
 
 var fenToPiece = map[byte]Piece{
@@ -1,7 +1,11 @@
 package chess
 
 import (
+	"errors"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestInitialization(t *testing.T) {
@@ -267,3 +271,155 @@ func TestNewFromFEN(t *testing.T) {
 		}
 	}
 }
+
+func TestParseSquare(t *testing.T) {
+	table := []struct {
+		name     string
+		expected Square
+		err      error
+	}{
+		{"a1", a1, nil},
+		{"e4", e4, nil},
+		{"H8", h8, nil},
+		{"i1", none, ErrSquare},
+		{"a9", none, ErrSquare},
+		{"", none, ErrSquare},
+		{"e4e5", none, ErrSquare},
+	}
+
+	for _, row := range table {
+		got, err := ParseSquare(row.name)
+		if got != row.expected || !errors.Is(err, row.err) {
+			t.Errorf("got: %v, %v, expected: %v, %v for %q\n", got, err, row.expected, row.err, row.name)
+		}
+		if err == nil && got.String() != strings.ToLower(row.name) {
+			t.Errorf("got: %s, expected: %s\n", got, strings.ToLower(row.name))
+		}
+	}
+}
+
+func TestBoardEditing(t *testing.T) {
+	b := NewBoard()
+	pieces := map[Square]Piece{e1: WhiteKing, e8: BlackKing, d1: WhiteQueen, a2: WhitePawn, b2: WhitePawn, f6: BlackKnight}
+	for s, p := range pieces {
+		assert.Nil(t, b.SetPiece(s, p))
+	}
+
+	got, err := b.PieceAt(d1)
+	assert.Nil(t, err)
+	assert.Equal(t, WhiteQueen, got)
+	got, err = b.PieceAt(d2)
+	assert.Nil(t, err)
+	assert.Equal(t, Empty, got)
+	assert.Equal(t, []Square{a2, b2}, b.Squares(WhitePawn))
+	assert.Equal(t, map[Square]Piece{e8: BlackKing, f6: BlackKnight}, b.Pieces(Black))
+	assert.Len(t, b.Pieces(White), 4)
+	assert.Len(t, b.Pieces(Both), 6)
+	assert.Equal(t, 11, b.Material(White))
+	assert.Equal(t, 3, b.Material(Black))
+
+	got, err = b.RemovePiece(d1)
+	assert.Nil(t, err)
+	assert.Equal(t, WhiteQueen, got)
+	got, _ = b.PieceAt(d1)
+	assert.Equal(t, Empty, got)
+	got, _ = b.RemovePiece(d1)
+	assert.Equal(t, Empty, got)
+	assert.Equal(t, 2, b.Material(White))
+}
+
+func TestBoardEditingErrors(t *testing.T) {
+	b := NewBoard()
+	for _, s := range []Square{none, h8 + 1} {
+		_, err := b.PieceAt(s)
+		assert.True(t, errors.Is(err, ErrSquare), s)
+		assert.True(t, errors.Is(b.SetPiece(s, WhiteKing), ErrSquare), s)
+		_, err = b.RemovePiece(s)
+		assert.True(t, errors.Is(err, ErrSquare), s)
+	}
+	for _, p := range []Piece{Empty, Pawn, King, BlackKing - 1} {
+		assert.True(t, errors.Is(b.SetPiece(e4, p), ErrPiece), p)
+	}
+	assert.Len(t, b.Pieces(Both), 0)
+}
+
+func TestBoardMirror(t *testing.T) {
+	table := []struct {
+		fen      string
+		expected string
+	}{
+		{
+			"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1",
+			"rnbqkbnr/pppp1ppp/8/4p3/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		},
+		{
+			"4k3/8/8/8/8/8/1Q6/4K3 w - - 0 1",
+			"4k3/1q6/8/8/8/8/8/4K3 b - - 0 1",
+		},
+	}
+
+	for _, row := range table {
+		g := NewGameFromFEN(row.fen)
+		expected := NewGameFromFEN(row.expected)
+		mirrored := g.Board.Mirror()
+		if !mirrored.Equal(expected.Board) {
+			t.Errorf("got: %v, expected: %v\n", mirrored.BoardMap(), expected.Board.BoardMap())
+		}
+		if !mirrored.Mirror().Equal(g.Board) {
+			t.Errorf("mirroring twice changed the board of %s\n", row.fen)
+		}
+		if mirrored.Equal(g.Board) {
+			t.Errorf("mirrored board equal to %s\n", row.fen)
+		}
+	}
+}
+
+func TestNewGameFromBoard(t *testing.T) {
+	table := []struct {
+		pieces      map[Square]Piece
+		turn        Color
+		expectedFen string
+		err         error
+	}{
+		{
+			pieces:      map[Square]Piece{e1: WhiteKing, h1: WhiteRook, a1: WhiteRook, e8: BlackKing, a8: BlackRook},
+			turn:        White,
+			expectedFen: "r3k3/8/8/8/8/8/8/R3K2R w KQq - 0 1",
+		},
+		{
+			pieces:      map[Square]Piece{d1: WhiteKing, h1: WhiteRook, e8: BlackKing, e7: BlackPawn},
+			turn:        Black,
+			expectedFen: "4k3/4p3/8/8/8/8/8/3K3R b - - 0 1",
+		},
+		{
+			pieces: map[Square]Piece{e1: WhiteKing},
+			turn:   White,
+			err:    ErrFENKingCount,
+		},
+		{
+			pieces: map[Square]Piece{e1: WhiteKing, e8: BlackKing, e2: WhiteRook},
+			turn:   White,
+			err:    ErrFENCheck,
+		},
+		{
+			pieces: map[Square]Piece{e1: WhiteKing, e8: BlackKing},
+			turn:   Both,
+			err:    ErrFENTurn,
+		},
+	}
+
+	for _, row := range table {
+		b := NewBoard()
+		for s, p := range row.pieces {
+			assert.Nil(t, b.SetPiece(s, p))
+		}
+		g, err := NewGameFromBoard(b, row.turn)
+		if !errors.Is(err, row.err) {
+			t.Errorf("got: %v, expected: %v\n", err, row.err)
+			continue
+		}
+		if err == nil {
+			assert.Equal(t, row.expectedFen, g.FenString())
+		}
+	}
+}
@@ -2,13 +2,16 @@ package chess
 
 import "fmt"
 
-//go:generate stringer -type=Color,Piece,Square,State,MovementType -output=stringer_gen.go
+//go:generate stringer -type=Color,Piece,Square,State,MovementType,Termination,EventType -output=stringer_gen.go
 
 type Color byte
 type Square int
 type State byte
 type MovementType byte
 type Piece int8
+type Termination byte
+type Result byte
+type EventType byte
 
 const (
 	BlackKing   Piece = iota - 6
@@ -47,6 +50,25 @@ const (
 	Both        // 3
 )
 
+// The lifecycle of a game, as a state machine:
+//
+//	Idle            -> Playing          Start
+//	Playing, Check  -> Playing, Check   a move
+//	Playing, Check  -> CheckMate, Draw  a move
+//	Playing, Check  -> Draw, Over       the clock, a resignation, abandonment,
+//	                                    a draw agreed or claimed
+//	Playing, Check  -> Paused           Pause
+//	Paused          -> Playing, Check   Resume, to the state it was paused in
+//	Idle, Playing,
+//	Check, Paused   -> Aborted          Abort, until both players have moved,
+//	                                    or End at any time
+//
+// CheckMate, Draw, Over and Aborted end the game. A move taken back with
+// Undo returns a game being played to the state before it, and so does a
+// move that ended the game by checkmate or an automatic draw; a game ended
+// any other way, paused or aborted can't be taken back. Redo only replays
+// moves in a game being played. Pause, Resume, Abort, Undo and Redo return
+// a *TransitionError when they are not allowed.
 const (
 	Idle State = iota
 	Playing
@@ -54,7 +76,49 @@ const (
 	CheckMate
 	Draw
 	Promo
-	Over // Timeout or anything else
+	Over    // Timeout or anything else
+	Paused  // The clocks are stopped and no moves can be made
+	Aborted // Stopped without a result
+)
+
+// Termination describes how a game ended
+const (
+	NoTermination Termination = iota
+	Checkmated
+	Stalemate
+	Resignation
+	Timeout
+	ThreefoldRepetition // Claimed by a player
+	FivefoldRepetition  // Declared automatically
+	FiftyMoveRule       // Claimed by a player
+	SeventyFiveMoveRule // Declared automatically
+	InsufficientMaterial
+	Agreement
+	Abandonment
+)
+
+// EventType tells what happened in a game, see Event
+const (
+	MoveEvent EventType = iota
+	CheckEvent
+	GameOverEvent
+	ClockEvent
+	LowTimeEvent
+	DrawOfferEvent
+	TakebackRequestEvent
+	TakebackEvent
+	PlayerJoinedEvent
+	PlayerLeftEvent
+	PauseEvent
+	ResumeEvent
+)
+
+// Result is the outcome of a game, as written in PGN
+const (
+	NoResult Result = iota
+	WhiteWon
+	BlackWon
+	Drawn
 )
 
 const (
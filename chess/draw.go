@@ -0,0 +1,125 @@
+package chess
+
+// Both rules count half moves since the last pawn move or capture
+const (
+	fiftyMoveRule       = 100
+	seventyFiveMoveRule = 150
+)
+
+// position holds everything that makes two positions identical in the
+// sense of the repetition rules: the placement of the pieces, the side to
+// move, the castling rights and the en passant square.
+type position struct {
+	board      [64]Piece
+	colorsTurn Color
+	castling   [4]bool
+	enPassant  Square
+}
+
+func (g *Game) position() position {
+	return position{
+		board:      g.Board.board,
+		colorsTurn: g.Context.ColorsTurn,
+		castling: [4]bool{
+			g.Context.whiteCanCastleRight,
+			g.Context.whiteCanCastleLeft,
+			g.Context.blackCanCastleRight,
+			g.Context.blackCanCastleLeft,
+		},
+		enPassant: g.Context.enPassantSquare,
+	}
+}
+
+// repetitions counts how many times the current position has occurred
+// in the game, including the current occurrence
+func (g *Game) repetitions() int {
+	current := g.position()
+	var cnt int
+	for _, pos := range g.positions {
+		if pos == current {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+// CanClaimThreefold reports if the current position has occurred at least
+// three times, which lets either player claim a draw
+func (g *Game) CanClaimThreefold() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.canClaimThreefold()
+}
+
+func (g *Game) canClaimThreefold() bool {
+	return g.repetitions() >= 3
+}
+
+// CanClaimFiftyMoves reports if the last fifty moves by each player were
+// made without a pawn move or a capture, which lets either player claim a draw
+func (g *Game) CanClaimFiftyMoves() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.canClaimFiftyMoves()
+}
+
+func (g *Game) canClaimFiftyMoves() bool {
+	return g.Context.halfMove >= fiftyMoveRule
+}
+
+func isDraw(player Color, board [64]Piece, ctx Context) bool {
+	moves := validMovesForPlayer(player, board, ctx)
+	if moves == nil {
+		return true
+	}
+	if insufficientMaterial(board) {
+		return true
+	}
+	return false
+}
+
+func drawTermination(player Color, board [64]Piece, ctx Context) Termination {
+	if insufficientMaterial(board) {
+		return InsufficientMaterial
+	}
+	return Stalemate
+}
+
+// insufficientMaterial reports if neither player can checkmate, which is
+// the case for king against king with at most a single knight or bishop
+// on the board, or with only bishops all standing on the same color
+func insufficientMaterial(b [64]Piece) bool {
+	var knights int
+	var bishops []Square
+	for sq := a1; sq <= h8; sq++ {
+		switch b[sq] {
+		case WhitePawn, BlackPawn, WhiteRook, BlackRook, WhiteQueen, BlackQueen:
+			return false
+		case WhiteKnight, BlackKnight:
+			knights++
+		case WhiteBishop, BlackBishop:
+			bishops = append(bishops, sq)
+		}
+	}
+	switch {
+	case knights+len(bishops) <= 1:
+		return true
+	case knights > 0:
+		return false
+	}
+	for _, sq := range bishops {
+		if sq.isLight() != bishops[0].isLight() {
+			return false
+		}
+	}
+	return true
+}
+
+// canCheckmate reports if player p could still checkmate the opponent,
+// used to decide if running out of time loses the game or draws it
+func canCheckmate(p Color, b [64]Piece) bool {
+	if len(squaresWithoutKing(p, b)) == 0 {
+		return false
+	}
+	return !insufficientMaterial(b)
+}
@@ -0,0 +1,256 @@
+package chess
+
+import (
+	"testing"
+)
+
+func TestRepetition(t *testing.T) {
+	shuffle := []string{"g1f3", "g8f6", "f3g1", "f6g8"}
+	table := []struct {
+		shuffles            int
+		expectedState       State
+		expectedTermination Termination
+		expectedCanClaim    bool
+		expectedClaimError  error
+	}{
+		{
+			shuffles:            1,
+			expectedState:       Playing,
+			expectedTermination: NoTermination,
+			expectedCanClaim:    false,
+			expectedClaimError:  ErrNoDrawClaim,
+		},
+		{
+			shuffles:            2,
+			expectedState:       Draw,
+			expectedTermination: ThreefoldRepetition,
+			expectedCanClaim:    true,
+			expectedClaimError:  nil,
+		},
+		{
+			shuffles:            4,
+			expectedState:       Draw,
+			expectedTermination: FivefoldRepetition,
+			expectedCanClaim:    true,
+			expectedClaimError:  ErrNotPlaying,
+		},
+	}
+
+	for _, row := range table {
+		g := NewGame()
+		g.Context.State = Playing
+		g.Players = []*Player{
+			{
+				Color: White,
+				ID:    "white",
+			},
+			{
+				Color: Black,
+				ID:    "black",
+			},
+		}
+		for i := 0; i < row.shuffles; i++ {
+			for _, move := range shuffle {
+				err := g.Move(move)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		if got := g.CanClaimThreefold(); got != row.expectedCanClaim {
+			t.Errorf("got: %v, expected: %v for %d shuffles\n", got, row.expectedCanClaim, row.shuffles)
+		}
+		if err := g.HandleClaimDraw("white"); err != row.expectedClaimError {
+			t.Errorf("got: %v, expected: %v for %d shuffles\n", err, row.expectedClaimError, row.shuffles)
+		}
+		if g.Context.State != row.expectedState || g.Context.Termination != row.expectedTermination {
+			t.Errorf("got: %s/%s, expected: %s/%s for %d shuffles\n",
+				g.Context.State, g.Context.Termination, row.expectedState, row.expectedTermination, row.shuffles)
+		}
+	}
+}
+
+func TestRepetitionIncludesCastlingRights(t *testing.T) {
+	g := NewGame()
+	g.Context.State = Playing
+	g.Players = []*Player{
+		{
+			Color: White,
+		},
+		{
+			Color: Black,
+		},
+	}
+	// The king walk loses the castling rights, so the second occurrence of
+	// the piece placement is a different position from the first.
+	for _, move := range []string{"e2e4", "e7e5", "e1e2", "e8e7", "e2e1", "e7e8", "e1e2", "e8e7", "e2e1", "e7e8"} {
+		err := g.Move(move)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := g.repetitions(); got != 2 {
+		t.Errorf("got: %d, expected: %d\n", got, 2)
+	}
+}
+
+func TestHalfMoveClock(t *testing.T) {
+	table := []struct {
+		fen              string
+		moves            []string
+		expectedHalfMove int
+	}{
+		{
+			fen:              "4k3/8/8/8/8/8/8/R3K3 w - - 10 30",
+			moves:            []string{"a1a2"},
+			expectedHalfMove: 11,
+		},
+		{
+			fen:              "4k3/r7/8/8/8/8/8/R3K3 w - - 10 30",
+			moves:            []string{"a1a7"},
+			expectedHalfMove: 0,
+		},
+		{
+			fen:              "4k3/8/8/8/8/8/4P3/R3K3 w - - 10 30",
+			moves:            []string{"e2e3"},
+			expectedHalfMove: 0,
+		},
+		{
+			fen:              "4k3/8/8/3pP3/8/8/8/R3K3 w - d6 10 30",
+			moves:            []string{"e5d6"},
+			expectedHalfMove: 0,
+		},
+	}
+
+	for _, row := range table {
+		g := NewGameFromFEN(row.fen)
+		g.Context.State = Playing
+		g.Players = []*Player{
+			{
+				Color: White,
+			},
+			{
+				Color: Black,
+			},
+		}
+		for _, move := range row.moves {
+			err := g.Move(move)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if g.Context.halfMove != row.expectedHalfMove {
+			t.Errorf("got: %d, expected: %d for %s\n", g.Context.halfMove, row.expectedHalfMove, row.fen)
+		}
+	}
+}
+
+func TestFiftyMoveRule(t *testing.T) {
+	table := []struct {
+		fen                 string
+		move                string
+		expectedState       State
+		expectedTermination Termination
+		expectedClaimError  error
+	}{
+		{
+			fen:                 "4k3/8/8/8/8/8/8/R3K3 w - - 98 80",
+			move:                "a1a2",
+			expectedState:       Playing,
+			expectedTermination: NoTermination,
+			expectedClaimError:  ErrNoDrawClaim,
+		},
+		{
+			fen:                 "4k3/8/8/8/8/8/8/R3K3 w - - 99 80",
+			move:                "a1a2",
+			expectedState:       Draw,
+			expectedTermination: FiftyMoveRule,
+			expectedClaimError:  nil,
+		},
+		{
+			fen:                 "4k3/8/8/8/8/8/8/R3K3 w - - 149 80",
+			move:                "a1a2",
+			expectedState:       Draw,
+			expectedTermination: SeventyFiveMoveRule,
+			expectedClaimError:  ErrNotPlaying,
+		},
+		{
+			fen:                 "4k3/8/4K3/8/8/8/8/R7 w - - 149 80",
+			move:                "a1a8",
+			expectedState:       CheckMate,
+			expectedTermination: Checkmated,
+			expectedClaimError:  ErrNotPlaying,
+		},
+	}
+
+	for _, row := range table {
+		g := NewGameFromFEN(row.fen)
+		g.Context.State = Playing
+		g.Players = []*Player{
+			{
+				Color: White,
+				ID:    "white",
+			},
+			{
+				Color: Black,
+				ID:    "black",
+			},
+		}
+		err := g.Move(row.move)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := g.HandleClaimDraw("black"); err != row.expectedClaimError {
+			t.Errorf("got: %v, expected: %v for %s\n", err, row.expectedClaimError, row.fen)
+		}
+		if g.Context.State != row.expectedState || g.Context.Termination != row.expectedTermination {
+			t.Errorf("got: %s/%s, expected: %s/%s for %s\n",
+				g.Context.State, g.Context.Termination, row.expectedState, row.expectedTermination, row.fen)
+		}
+	}
+}
+
+func TestInsufficientMaterial(t *testing.T) {
+	table := []struct {
+		fen      string
+		expected bool
+	}{
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0 1", true},
+		{"4k3/8/8/8/8/8/8/2B1K3 w - - 0 1", true},
+		{"4k3/8/8/8/8/8/8/1N2K3 w - - 0 1", true},
+		{"2b1k3/8/8/8/8/8/8/2B1K3 w - - 0 1", false},
+		{"2b1k3/8/8/8/8/8/8/3BK3 w - - 0 1", true},
+		{"4k3/8/8/8/8/8/8/2BBK3 w - - 0 1", false},
+		{"4k3/8/8/8/8/8/8/1NN1K3 w - - 0 1", false},
+		{"1n2k3/8/8/8/8/8/8/1N2K3 w - - 0 1", false},
+		{"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", false},
+		{"4k3/8/8/8/8/8/8/R3K3 w - - 0 1", false},
+	}
+
+	for _, row := range table {
+		got := insufficientMaterial(NewGameFromFEN(row.fen).Board.board)
+		if got != row.expected {
+			t.Errorf("got: %v, expected: %v for %s\n", got, row.expected, row.fen)
+		}
+	}
+}
+
+func TestInsufficientMaterialDraw(t *testing.T) {
+	g := NewGameFromFEN("4k3/8/8/8/8/8/3r4/4K3 w - - 0 1")
+	g.Context.State = Playing
+	g.Players = []*Player{
+		{
+			Color: White,
+		},
+		{
+			Color: Black,
+		},
+	}
+	err := g.Move("e1d2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Context.State != Draw || g.Context.Termination != InsufficientMaterial {
+		t.Errorf("got: %s/%s, expected: %s/%s\n", g.Context.State, g.Context.Termination, Draw, InsufficientMaterial)
+	}
+}
@@ -0,0 +1,112 @@
+package chess
+
+import "time"
+
+const (
+	// Events a subscriber hasn't received yet, further events are dropped
+	eventBufferSize = 64
+	// Clock events are sent at most this often
+	clockEventInterval = time.Second
+	// A low time event is sent once a player has less time than this left
+	lowTimeThreshold = 10 * time.Second
+)
+
+// Event is something that happened in a game, with the context of the game
+// and the clocks of the players right after it
+type Event struct {
+	Type      EventType
+	Color     Color  // Player who made the move, offer or request, or joined or left
+	PlayerID  string // ID of that player, if any
+	Move      *Move  // Move made or taken back
+	SAN       string // Move in standard algebraic notation
+	Context   Context
+	WhiteTime time.Duration
+	BlackTime time.Duration
+}
+
+// Subscribe returns a channel the events of the game are sent on. Events
+// are never waited for: a subscriber that falls more than 64 events
+// behind misses the events that follow until it catches up.
+func (g *Game) Subscribe() <-chan Event {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ch := make(chan Event, eventBufferSize)
+	g.subscribers = append(g.subscribers, ch)
+	return ch
+}
+
+// Unsubscribe stops sending events on the channel and closes it
+func (g *Game) Unsubscribe(ch <-chan Event) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, sub := range g.subscribers {
+		if sub == ch {
+			close(sub)
+			g.subscribers = append(g.subscribers[:i], g.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// publish sends the event to the subscribers that have room for it, the
+// context and clocks are filled in from the game
+func (g *Game) publish(e Event) {
+	if len(g.subscribers) == 0 {
+		return
+	}
+	e.Context = g.Context
+	for _, p := range g.Players {
+		switch p.Color {
+		case White:
+			e.WhiteTime = g.timeLeft(p)
+		case Black:
+			e.BlackTime = g.timeLeft(p)
+		}
+	}
+	for _, sub := range g.subscribers {
+		select {
+		case sub <- e:
+		default:
+		}
+	}
+}
+
+// publishPlayer publishes an event about the player
+func (g *Game) publishPlayer(t EventType, p *Player) {
+	g.publish(Event{Type: t, Color: p.Color, PlayerID: p.ID})
+}
+
+// publishMove publishes the move just made, and the check or the end of
+// the game it gave
+func (g *Game) publishMove(m Move, p *Player) {
+	g.publish(Event{Type: MoveEvent, Color: p.Color, PlayerID: p.ID, Move: &m, SAN: m.san})
+	switch g.Context.State {
+	case Check:
+		g.publish(Event{Type: CheckEvent, Color: p.Color, PlayerID: p.ID})
+	case CheckMate, Draw, Over:
+		g.publishGameOver()
+	}
+}
+
+func (g *Game) publishGameOver() {
+	g.publish(Event{Type: GameOverEvent})
+}
+
+// publishClock publishes the clocks every clockEventInterval, and warns
+// once when the side to move gets low on time
+func (g *Game) publishClock() {
+	now := timeNow()
+	if now-g.lastClockEvent >= int64(clockEventInterval) {
+		g.lastClockEvent = now
+		g.publish(Event{Type: ClockEvent})
+	}
+	p := g.getPlayer(g.Context.ColorsTurn)
+	low := g.timeLeft(p) < lowTimeThreshold
+	if low && !g.lowTime[p.Color] {
+		g.publishPlayer(LowTimeEvent, p)
+	}
+	if g.lowTime == nil {
+		g.lowTime = map[Color]bool{}
+	}
+	g.lowTime[p.Color] = low
+}
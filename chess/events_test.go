@@ -0,0 +1,166 @@
+package chess
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// received returns the events waiting on the channel
+func received(ch <-chan Event) []Event {
+	var events []Event
+	for {
+		select {
+		case e := <-ch:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func eventTypes(events []Event) []EventType {
+	var types []EventType
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func TestSubscribe(t *testing.T) {
+	g := NewGame()
+	events := g.Subscribe()
+
+	assert.Nil(t, g.HandlePick("white", "white"))
+	assert.Nil(t, g.HandlePick("early", "black"))
+	assert.Nil(t, g.HandleLeave("early"))
+	assert.Equal(t, ErrNotInGame, g.HandleLeave("nobody"))
+	assert.Nil(t, g.HandlePick("black", "black"))
+	got := received(events)
+	assert.Equal(t, []EventType{PlayerJoinedEvent, PlayerJoinedEvent, PlayerLeftEvent, PlayerJoinedEvent}, eventTypes(got))
+	assert.Equal(t, "early", got[2].PlayerID)
+	assert.Equal(t, Black, got[2].Color)
+
+	g.Context.State = Playing
+	for _, m := range []string{"e4", "e5", "Bc4", "Nc6", "Qh5", "Nf6"} {
+		assert.Nil(t, g.PlayMove(m))
+	}
+	assert.Nil(t, g.HandleOfferDraw("white"))
+	assert.Nil(t, g.HandleTakebackRequest("black"))
+	assert.Nil(t, g.HandleTakebackAccept("white"))
+	assert.Nil(t, g.PlayMove("Nf6"))
+	assert.Nil(t, g.PlayMove("Qxf7"))
+
+	got = received(events)
+	assert.Equal(t, []EventType{
+		MoveEvent, MoveEvent, MoveEvent, MoveEvent, MoveEvent, MoveEvent,
+		DrawOfferEvent, TakebackRequestEvent, TakebackEvent,
+		MoveEvent, MoveEvent, GameOverEvent,
+	}, eventTypes(got))
+
+	first := got[0]
+	assert.Equal(t, White, first.Color)
+	assert.Equal(t, "white", first.PlayerID)
+	assert.Equal(t, "e4", first.SAN)
+	assert.Equal(t, "e2e4", first.Move.UCI())
+	assert.Equal(t, Black, first.Context.ColorsTurn)
+
+	takeback := got[8]
+	assert.Equal(t, "Nf6", takeback.SAN)
+	assert.Equal(t, Black, takeback.Context.ColorsTurn)
+
+	over := got[len(got)-1]
+	assert.Equal(t, "Qxf7#", got[len(got)-2].SAN)
+	assert.Equal(t, CheckMate, over.Context.State)
+	assert.Equal(t, Checkmated, over.Context.Termination)
+	assert.Equal(t, WhiteWon, over.Context.Result())
+}
+
+func TestLeaveUnknown(t *testing.T) {
+	g := NewGame()
+	events := g.Subscribe()
+	assert.Equal(t, ErrNotInGame, g.HandleLeave("nobody"))
+
+	assert.Nil(t, g.HandlePick("white", "white"))
+	assert.Equal(t, ErrNotInGame, g.HandleLeave("nobody"))
+	assert.Len(t, g.Players, 1)
+	assert.Equal(t, []EventType{PlayerJoinedEvent}, eventTypes(received(events)))
+}
+
+func TestSubscribeCheck(t *testing.T) {
+	g := NewGameFromFEN("4k3/8/8/8/8/8/8/R3K3 w - - 0 1")
+	g.Context.State = Playing
+	g.Players = []*Player{{Color: White, ID: "white"}, {Color: Black, ID: "black"}}
+	events := g.Subscribe()
+
+	assert.Nil(t, g.PlayMove("Ra8+"))
+	assert.Nil(t, g.PlayMove("Kd7"))
+	assert.Nil(t, g.HandleResign("black"))
+	got := received(events)
+	assert.Equal(t, []EventType{MoveEvent, CheckEvent, MoveEvent, GameOverEvent}, eventTypes(got))
+	assert.Equal(t, White, got[1].Color)
+	assert.Equal(t, Check, got[1].Context.State)
+	assert.Equal(t, Playing, got[2].Context.State)
+	assert.Equal(t, Resignation, got[3].Context.Termination)
+}
+
+func TestSubscribeClock(t *testing.T) {
+	clock := useFakeClock()
+	defer clock.restore()
+
+	g := NewGame()
+	g.Players = []*Player{{Color: White, ID: "white"}, {Color: Black, ID: "black"}}
+	g.StartingTime = 30 * time.Second
+	events := g.Subscribe()
+	cleanup := g.Start()
+	defer cleanup()
+	tick := func() {
+		g.mu.Lock()
+		g.tick()
+		g.mu.Unlock()
+	}
+
+	tick()
+	clock.advance(clockEventInterval / 2)
+	tick()
+	clock.advance(clockEventInterval / 2)
+	tick()
+	got := received(events)
+	assert.Equal(t, []EventType{ClockEvent, ClockEvent}, eventTypes(got))
+	assert.Equal(t, 29*time.Second, got[1].WhiteTime)
+	assert.Equal(t, 30*time.Second, got[1].BlackTime)
+
+	// The warning is given once
+	clock.advance(20 * time.Second)
+	tick()
+	clock.advance(clockEventInterval)
+	tick()
+	got = received(events)
+	assert.Equal(t, []EventType{ClockEvent, LowTimeEvent, ClockEvent}, eventTypes(got))
+	assert.Equal(t, White, got[1].Color)
+
+	clock.advance(10 * time.Second)
+	tick()
+	got = received(events)
+	assert.Equal(t, []EventType{GameOverEvent}, eventTypes(got))
+	assert.Equal(t, Timeout, got[0].Context.Termination)
+}
+
+func TestSubscribeSlow(t *testing.T) {
+	g := NewGame()
+	g.Context.State = Playing
+	g.Players = []*Player{{Color: White, ID: "white"}, {Color: Black, ID: "black"}}
+	slow := g.Subscribe()
+	gone := g.Subscribe()
+	g.Unsubscribe(gone)
+	_, open := <-gone
+	assert.False(t, open)
+
+	// Nobody reads the events, the game goes on regardless
+	for i := 0; i < eventBufferSize; i++ {
+		assert.Nil(t, g.PlayMove("e4"))
+		assert.Nil(t, g.Undo())
+	}
+	assert.Len(t, received(slow), eventBufferSize)
+}
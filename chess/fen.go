@@ -0,0 +1,339 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrFENFieldCount  = errors.New("expected six fields")
+	ErrFENRankCount   = errors.New("expected eight ranks")
+	ErrFENRankLength  = errors.New("expected eight squares in rank")
+	ErrFENPiece       = errors.New("unknown piece")
+	ErrFENTurn        = errors.New("unknown side to move")
+	ErrFENCastling    = errors.New("invalid castling rights")
+	ErrFENEnPassant   = errors.New("invalid en passant square")
+	ErrFENMoveCounter = errors.New("invalid move counter")
+	ErrFENKingCount   = errors.New("expected one king of each color")
+	ErrFENPawnRank    = errors.New("pawn on first or last rank")
+	ErrFENCheck       = errors.New("side not to move is in check")
+)
+
+// FENError is returned when a FEN string can't be parsed, Err is one of
+// the ErrFEN errors
+type FENError struct {
+	FEN    string
+	Err    error
+	Detail string
+}
+
+func (e *FENError) Error() string {
+	return fmt.Sprintf("invalid fen %q: %s: %s", e.FEN, e.Err, e.Detail)
+}
+
+func (e *FENError) Unwrap() error {
+	return e.Err
+}
+
+// ParseFEN creates a game from a FEN string, returning a *FENError if
+// the string is malformed or describes a position that can't occur
+func ParseFEN(fen string) (*Game, error) {
+	return parseFEN(fen, true)
+}
+
+// NewGameFromFEN creates a game from a FEN string, and panics if it is
+// malformed. The position itself is not validated, so boards without
+// kings can be set up, use ParseFEN for input from users.
+func NewGameFromFEN(fen string) *Game {
+	g, err := parseFEN(fen, false)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// parseFEN parses the fen, strict also requires all ranks to be complete
+// and validates the position
+func parseFEN(fen string, strict bool) (*Game, error) {
+	fenError := func(err error, format string, a ...interface{}) error {
+		return &FENError{FEN: fen, Err: err, Detail: fmt.Sprintf(format, a...)}
+	}
+
+	splitted := strings.Fields(fen)
+	if len(splitted) != 6 {
+		return nil, fenError(ErrFENFieldCount, "got %d", len(splitted))
+	}
+	board := splitted[0]
+	turn := splitted[1]
+	castle := splitted[2]
+	enPassant := splitted[3]
+	halfMove := splitted[4]
+	fullMove := splitted[5]
+	ranks := strings.Split(board, "/")
+	if len(ranks) > 8 || (strict && len(ranks) != 8) {
+		return nil, fenError(ErrFENRankCount, "got %d", len(ranks))
+	}
+
+	eb := NewEmptyGame()
+	var i, j, row, col int
+	for i = 0; i < len(ranks); i++ {
+		row = 7 - i
+		col = 0
+		for j = 0; j < len(ranks[i]); j++ {
+			piece, found := fenToPiece[ranks[i][j]]
+			if !found {
+				return nil, fenError(ErrFENPiece, "%q in rank %d", ranks[i][j], row+1)
+			}
+			if piece == Empty {
+				col += int(ranks[i][j] - '0')
+				continue
+			}
+			if col > 7 {
+				return nil, fenError(ErrFENRankLength, "rank %d", row+1)
+			}
+			eb.Board.board[row*8+col] = piece
+			col += 1
+		}
+		if strict && col != 8 {
+			return nil, fenError(ErrFENRankLength, "rank %d has %d squares", row+1, col)
+		}
+	}
+
+	switch turn {
+	case "w":
+		eb.Context.ColorsTurn = White
+	case "b":
+		eb.Context.ColorsTurn = Black
+	default:
+		return nil, fenError(ErrFENTurn, "%q", turn)
+	}
+
+	eb.Context.whiteCanCastleLeft = false
+	eb.Context.whiteCanCastleRight = false
+	eb.Context.blackCanCastleRight = false
+	eb.Context.blackCanCastleLeft = false
+	if castle != "-" {
+		for _, b := range castle {
+			var right *bool
+			switch b {
+			case 'K':
+				right = &eb.Context.whiteCanCastleRight
+			case 'Q':
+				right = &eb.Context.whiteCanCastleLeft
+			case 'k':
+				right = &eb.Context.blackCanCastleRight
+			case 'q':
+				right = &eb.Context.blackCanCastleLeft
+			default:
+				return nil, fenError(ErrFENCastling, "%q", castle)
+			}
+			if *right {
+				return nil, fenError(ErrFENCastling, "%q", castle)
+			}
+			*right = true
+		}
+	}
+
+	switch sq := enPassant; {
+	case sq == "-":
+		eb.Context.enPassantSquare = none
+	default:
+		epSquare, found := stringToSquare[sq]
+		if !found {
+			return nil, fenError(ErrFENEnPassant, "%q", sq)
+		}
+		eb.Context.enPassantSquare = epSquare
+	}
+
+	var halfMoveInt, fullMoveInt int
+	var err error
+	halfMoveInt, err = strconv.Atoi(halfMove)
+	if err != nil || halfMoveInt < 0 {
+		return nil, fenError(ErrFENMoveCounter, "half move %q", halfMove)
+	}
+	eb.Context.halfMove = halfMoveInt
+	fullMoveInt, err = strconv.Atoi(fullMove)
+	if err != nil || fullMoveInt < 0 || (strict && fullMoveInt < 1) {
+		return nil, fenError(ErrFENMoveCounter, "full move %q", fullMove)
+	}
+	eb.Context.fullMove = fullMoveInt
+
+	if strict {
+		if err := validatePosition(eb); err != nil {
+			err.(*FENError).FEN = fen
+			return nil, err
+		}
+		// Only keep an en passant square that can be captured on, so that
+		// equal positions have equal contexts
+		if !canCaptureEnPassant(eb.Context.enPassantSquare, eb.Board.board, eb.Context.ColorsTurn) {
+			eb.Context.enPassantSquare = none
+		}
+	}
+	eb.initialFEN = eb.FenString()
+	return eb, nil
+}
+
+// validatePosition checks that the position of the game could occur in a game
+func validatePosition(g *Game) error {
+	fenError := func(err error, format string, a ...interface{}) error {
+		return &FENError{FEN: g.fenString(), Err: err, Detail: fmt.Sprintf(format, a...)}
+	}
+	b := g.Board.board
+	ctx := g.Context
+
+	for _, king := range []Piece{WhiteKing, BlackKing} {
+		if n := len(getPieceSquares(king, b)); n != 1 {
+			return fenError(ErrFENKingCount, "%d %s", n, king)
+		}
+	}
+
+	for sq := a1; sq <= h8; sq++ {
+		if (b[sq] == WhitePawn || b[sq] == BlackPawn) && (sq.rank() == 1 || sq.rank() == 8) {
+			return fenError(ErrFENPawnRank, "%s on %s", b[sq], sq)
+		}
+	}
+
+	castling := []struct {
+		canCastle bool
+		king      Piece
+		kingSq    Square
+		rookSq    Square
+	}{
+		{ctx.whiteCanCastleRight, WhiteKing, e1, h1},
+		{ctx.whiteCanCastleLeft, WhiteKing, e1, a1},
+		{ctx.blackCanCastleRight, BlackKing, e8, h8},
+		{ctx.blackCanCastleLeft, BlackKing, e8, a8},
+	}
+	for _, c := range castling {
+		if !c.canCastle {
+			continue
+		}
+		rook := WhiteRook
+		if c.king == BlackKing {
+			rook = BlackRook
+		}
+		if b[c.kingSq] != c.king || b[c.rookSq] != rook {
+			return fenError(ErrFENCastling, "no %s on %s and %s on %s", c.king, c.kingSq, rook, c.rookSq)
+		}
+	}
+
+	if ep := ctx.enPassantSquare; ep != none {
+		// The pawn that just moved two squares stands in front of the
+		// en passant square, seen from the side to move
+		var rank Square = 6
+		var pawn = BlackPawn
+		var front, behind = ep - 8, ep + 8
+		if ctx.ColorsTurn == Black {
+			rank = 3
+			pawn = WhitePawn
+			front, behind = ep+8, ep-8
+		}
+		if ep.rank() != rank || b[ep] != Empty || b[behind] != Empty || b[front] != pawn {
+			return fenError(ErrFENEnPassant, "%s with %s to move", ep, ctx.ColorsTurn)
+		}
+	}
+
+	opponent := ctx.ColorsTurn.opponent()
+	if inCheck(getKingSquareMust(opponent, b), b) {
+		return fenError(ErrFENCheck, "%s", opponent)
+	}
+	return nil
+}
+
+func (g *Game) FenString() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.fenString()
+}
+
+func (g *Game) fenString() string {
+	var cnt int
+	var board string
+	var sq Square
+	for i := 7; i >= 0; i-- {
+		cnt = 0
+		for j := 0; j < 8; j++ {
+			sq = Square(i*8 + j)
+
+			switch p := g.Board.board[sq]; {
+			case p == Empty:
+				cnt += 1
+			default:
+				if cnt > 0 {
+					board += strconv.Itoa(cnt)
+				}
+				cnt = 0
+				board += pieceToFen[p]
+			}
+			if j == 7 {
+				if cnt == 0 {
+					board += "/"
+					continue
+				}
+				board += strconv.Itoa(cnt) + "/"
+				cnt = 0
+			}
+		}
+	}
+	board = strings.TrimSuffix(board, "/")
+
+	toMove := playerToFen[g.Context.ColorsTurn]
+
+	var castle string
+	if g.Context.whiteCanCastleRight {
+		castle += pieceToFen[WhiteKing]
+	}
+	if g.Context.whiteCanCastleLeft {
+		castle += pieceToFen[WhiteQueen]
+	}
+	if g.Context.blackCanCastleRight {
+		castle += pieceToFen[BlackKing]
+	}
+	if g.Context.blackCanCastleLeft {
+		castle += pieceToFen[BlackQueen]
+	}
+
+	if castle == "" {
+		castle = "-"
+	}
+
+	var enpassant string
+	if canCaptureEnPassant(g.Context.enPassantSquare, g.Board.board, g.Context.ColorsTurn) {
+		enpassant = g.Context.enPassantSquare.String()
+	} else {
+		enpassant = "-"
+	}
+	halfMove := strconv.Itoa(g.Context.halfMove)
+	fullMove := strconv.Itoa(g.Context.fullMove)
+	return fmt.Sprintf("%s %s %s %s %s %s", board, toMove, castle, enpassant, halfMove, fullMove)
+}
+
+// canCaptureEnPassant reports if a pawn of player p stands next to the
+// pawn that passed the en passant square ep
+func canCaptureEnPassant(ep Square, b [64]Piece, p Color) bool {
+	if ep == none {
+		return false
+	}
+	var passed Square
+	var pawn Piece
+	switch p {
+	case White:
+		passed, pawn = ep-8, WhitePawn
+	case Black:
+		passed, pawn = ep+8, BlackPawn
+	default:
+		return false
+	}
+	if passed < a1 || h8 < passed {
+		return false
+	}
+	if passed.col() > 0 && b[passed-1] == pawn {
+		return true
+	}
+	if passed.col() < 7 && b[passed+1] == pawn {
+		return true
+	}
+	return false
+}
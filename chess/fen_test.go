@@ -0,0 +1,175 @@
+package chess
+
+import (
+	"errors"
+	"flag"
+	"math/rand"
+	"testing"
+)
+
+func TestParseFEN(t *testing.T) {
+	table := []struct {
+		fen         string
+		expectedErr error
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", nil},
+		{"rnbqkbnr/pppp1ppp/8/8/3pP3/8/PPP2PPP/RNBQKBNR b KQkq e3 0 3", nil},
+		{"4k3/8/8/8/8/8/8/4K3 b - - 99 120", nil},
+		{"", ErrFENFieldCount},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -", ErrFENFieldCount},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 extra", ErrFENFieldCount},
+		{"rnbqkbnr/pppppppp/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", ErrFENRankCount},
+		{"rnbqkbnr/pppppppp/8/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", ErrFENRankCount},
+		{"rnbqkbnr/pppppppp/8/8/7/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", ErrFENRankLength},
+		{"rnbqkbnr/pppppppp/8/8/9/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", ErrFENPiece},
+		{"rnbqkbnr/ppppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", ErrFENRankLength},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNX w KQkq - 0 1", ErrFENPiece},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - 0 1", ErrFENTurn},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkx - 0 1", ErrFENCastling},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KKkq - 0 1", ErrFENCastling},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBN1 w KQkq - 0 1", ErrFENCastling},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQ1BNR w kq - 0 1", ErrFENKingCount},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBKKBNR w kq - 0 1", ErrFENKingCount},
+		{"rnbqkbnP/pppppppp/8/8/8/8/PPPPPPP1/RNBQKBNR w KQq - 0 1", ErrFENPawnRank},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e9 0 1", ErrFENEnPassant},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e3 0 1", ErrFENEnPassant},
+		{"rnbqkbnr/pppp1ppp/8/8/3pP3/8/PPP2PPP/RNBQKBNR w KQkq e3 0 3", ErrFENEnPassant},
+		{"rnbqkbnr/pppp1ppp/8/8/3pP3/8/PPP2PPP/RNBQKBNR b KQkq d3 0 3", ErrFENEnPassant},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - x 1", ErrFENMoveCounter},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - -1 1", ErrFENMoveCounter},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 0", ErrFENMoveCounter},
+		{"4k3/8/8/8/8/8/8/4R1K1 w - - 0 1", ErrFENCheck},
+	}
+
+	for _, row := range table {
+		g, err := ParseFEN(row.fen)
+		if !errors.Is(err, row.expectedErr) {
+			t.Errorf("got: %v, expected: %v for %q\n", err, row.expectedErr, row.fen)
+			continue
+		}
+		if err != nil {
+			var fenErr *FENError
+			if !errors.As(err, &fenErr) || fenErr.FEN != row.fen {
+				t.Errorf("got: %#v, expected a *FENError for %q\n", err, row.fen)
+			}
+			if g != nil {
+				t.Errorf("got a game for %q\n", row.fen)
+			}
+			continue
+		}
+		if g.FenString() != row.fen {
+			t.Errorf("got: %s, expected: %s\n", g.FenString(), row.fen)
+		}
+	}
+}
+
+func TestNewGameFromFENPanics(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected a panic on a malformed fen")
+		}
+	}()
+	NewGameFromFEN("rnbqkbnr/pppppppp w KQkq")
+}
+
+// The full sweep of thousands of random games takes a while, -short plays
+// at most 200 of them
+var fenPlayouts = flag.Int("fen-playouts", 2000, "random games played by TestFENRoundTrip")
+
+func TestFENRoundTrip(t *testing.T) {
+	playouts := *fenPlayouts
+	if testing.Short() && playouts > 200 {
+		playouts = 200
+	}
+	const maxPlies = 120
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < playouts; i++ {
+		g := NewGame()
+		g.Context.State = Playing
+		g.Players = []*Player{
+			{
+				Color: White,
+			},
+			{
+				Color: Black,
+			},
+		}
+		for ply := 0; ply < maxPlies; ply++ {
+			fen := g.FenString()
+			parsed, err := ParseFEN(fen)
+			if err != nil {
+				t.Fatalf("playout %d, ply %d: %v\n", i, ply, err)
+			}
+			if got := parsed.FenString(); got != fen {
+				t.Fatalf("playout %d, ply %d: got: %s, expected: %s\n", i, ply, got, fen)
+			}
+			if parsed.Board.board != g.Board.board || parsed.position() != g.position() {
+				t.Fatalf("playout %d, ply %d: position differs after parsing %s\n", i, ply, fen)
+			}
+			if parsed.Context.halfMove != g.Context.halfMove || parsed.Context.fullMove != g.Context.fullMove {
+				t.Fatalf("playout %d, ply %d: counters differ after parsing %s\n", i, ply, fen)
+			}
+
+			moves, err := ValidMoves(g.Board, g.Context.ColorsTurn, g.Context)
+			if err != nil {
+				break
+			}
+			err = g.Move(moves[r.Intn(len(moves))])
+			if err != nil {
+				t.Fatalf("playout %d, ply %d: %v\n", i, ply, err)
+			}
+		}
+	}
+}
+
+func TestFenString(t *testing.T) {
+	table := []struct {
+		fen      string
+		moves    []string
+		expected string
+	}{
+		{
+			fen:      "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			moves:    []string{"h1h2"},
+			expected: "r3k2r/8/8/8/8/8/7R/R3K3 b Qkq - 1 1",
+		},
+		{
+			fen:      "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1",
+			moves:    []string{"h8h7"},
+			expected: "r3k3/7r/8/8/8/8/8/R3K2R w KQq - 1 2",
+		},
+		{
+			fen:      "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			moves:    []string{"e2e4"},
+			expected: "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1",
+		},
+		{
+			fen:      "rnbqkbnr/ppp1pppp/8/8/3p4/8/PPPPPPPP/RNBQKBNR w KQkq - 0 3",
+			moves:    []string{"e2e4"},
+			expected: "rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 3",
+		},
+	}
+
+	for _, row := range table {
+		g := NewGameFromFEN(row.fen)
+		g.Context.State = Playing
+		g.Players = []*Player{
+			{
+				Color: White,
+			},
+			{
+				Color: Black,
+			},
+		}
+		for _, move := range row.moves {
+			err := g.Move(move)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := g.FenString(); got != row.expected {
+			t.Errorf("got: %s, expected: %s\n", got, row.expected)
+		}
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,78 +18,246 @@ var (
 
 	ErrAlreadyPlaying = errors.New("player already seated")
 	ErrColorTaken     = errors.New("such Color already taken")
+	ErrNotInGame      = errors.New("player not in game")
+	ErrNoDrawClaim    = errors.New("no draw can be claimed in this position")
+	ErrNoDrawOffer    = errors.New("no draw offered by the opponent")
 )
 
+// Game is safe for concurrent use, its exported methods and the clock
+// started by Start are serialized by a mutex. The exported fields must not
+// be read while the game is being played, use CurrentContext and TimeLeft.
 type Game struct {
+	mu sync.Mutex
+
 	Board        *Board
 	Context      Context
 	Players      []*Player
 	Moves        []*Move
 	StartingTime time.Duration
-	startedAt    int64
+	startedAt    int64 // Wall clock time of the start, in nanoseconds since the epoch
+	positions    []position
+	drawOffer    Color
+	initialFEN   string // Starting position, empty for the standard one
+
+	timed         bool
+	turnStartedAt int64 // When the clock of the side to move was started
+	turnSpent     time.Duration
+	timeControls  map[Color]TimeControl
+	pausedState   State         // State to resume a paused game in
+	stop          func()        // Stops the goroutine started by Start
+	stopped       chan struct{} // Closed once that goroutine has returned
+
+	subscribers    []chan Event
+	lastClockEvent int64
+	lowTime        map[Color]bool // Players warned they are low on time
+	moved          map[Color]bool // Players who made a move, taken back or not
+
+	takebackRequest Color
+	history         []snapshot // Games before each of the Moves
+	redo            []Move     // Moves taken back, the last one first to replay
 }
 
+// Start starts the clocks of an Idle game and lets the players move. The
+// goroutine running the clocks returns once the game is over, the returned
+// cleanup function ends the game, see End, and waits for it. A game that
+// is not Idle is left as it is.
 func (g *Game) Start() func() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Context.State != Idle {
+		return func() {}
+	}
+	// Only a game with both players on the clock is timed
+	timed := g.findPlayer(White) != nil && g.findPlayer(Black) != nil
 	for _, p := range g.Players {
-		p.TimeLeft = g.StartingTime
+		tc := g.timeControl(p.Color)
+		if len(tc.Periods) == 0 {
+			timed = false
+			continue
+		}
+		p.TimeLeft = tc.Periods[0].Time
 	}
+	// A game set up or read from PGN can start with the side to move in check
 	g.Context.State = Playing
-	g.startedAt = timeNow()
-	exit := make(chan bool)
-	ticker := time.NewTicker(gameUpdateInterval)
-	cleanup := func() {
-		exit <- true
-		ticker.Stop()
-	}
-	runAsync := func() {
+	if inCheck(getKingSquareMust(g.Context.ColorsTurn, g.Board.board), g.Board.board) {
+		g.Context.State = Check
+	}
+	g.startedAt = time.Now().UnixNano()
+	g.timed = timed
+	if timed {
+		g.turnStartedAt = timeNow()
+	}
+	g.run()
+	return func() {
+		g.End()
+		g.mu.Lock()
+		stopped := g.stopped
+		g.mu.Unlock()
+		<-stopped
+	}
+}
+
+// run starts the goroutine running the clocks, it returns once the game
+// is halted
+func (g *Game) run() {
+	exit := make(chan struct{})
+	stopped := make(chan struct{})
+	var once sync.Once
+	g.stop = func() {
+		once.Do(func() { close(exit) })
+	}
+	g.stopped = stopped
+	go func() {
+		ticker := time.NewTicker(gameUpdateInterval)
+		defer close(stopped)
+		defer ticker.Stop()
 		for {
 			select {
 			case <-exit:
-				g.End()
+				return
 			case <-ticker.C:
-				p := g.getPlayer(g.Context.ColorsTurn)
-				p.TimeLeft -= gameUpdateInterval
-				if p.TimeLeft < 0 {
-					opp := g.getOpponent(p)
-					g.Context.WinningPlayer = opp
-					g.Context.State = Over
-				}
+				g.mu.Lock()
+				g.tick()
+				g.mu.Unlock()
 			}
 		}
+	}()
+}
+
+// tick ends the game when the side to move has run out of time, the time
+// spent is charged when the turn ends
+func (g *Game) tick() {
+	if g.outOfTime() {
+		g.stopClock()
+		g.flag()
+		g.publishGameOver()
+		return
+	}
+	if g.clockRunning() {
+		g.publishClock()
 	}
-	go runAsync()
+}
 
-	return cleanup
+// CurrentContext returns a copy of the context of the game
+func (g *Game) CurrentContext() Context {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.Context
 }
 
-func (g *Game) End() {
-	g.Context.State = Idle
+// TimeLeft returns the time left on the clock of the player of color c, 0
+// if no player has that color
+func (g *Game) TimeLeft(c Color) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if p := g.findPlayer(c); p != nil {
+		return g.timeLeft(p)
+	}
+	return 0
 }
 
 // Move gets squares in human readable form, and performs a move
 // error is nil on successful move
-// arguments are two squares : "e2e4"
+// arguments are two squares : "e2e4", and the piece to promote
+// to for pawns reaching the last rank : "e7e8n". A pawn promotes
+// to a queen if no piece is given.
 func (g *Game) Move(moveStr string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	if g.Context.State != Playing && g.Context.State != Check {
 		return fmt.Errorf("not in playing state")
 	}
-	fromSquare, toSquare, err := g.Board.getSquare(moveStr)
+	fromSquare, toSquare, promo, err := g.Board.getSquare(moveStr)
 	if err != nil {
 		return err
 	}
 
-	return g.move(fromSquare, toSquare)
+	return g.move(fromSquare, toSquare, promo)
+}
+
+// PlayMove performs a move given in any common notation: coordinates as
+// used by UCI, "g1f3", long algebraic, "Ng1-f3", or standard algebraic
+// notation, "Nf3", "O-O". error is nil on successful move, a *NoMoveError
+// if no legal move matches the notation.
+func (g *Game) PlayMove(notation string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.playMove(notation)
+}
+
+func (g *Game) playMove(notation string) error {
+	if g.Context.State != Playing && g.Context.State != Check {
+		return ErrNotPlaying
+	}
+	m, err := parseMove(notation, g.Board.board, g.Context)
+	if err != nil {
+		return err
+	}
+	return g.move(m.FromSquare, m.ToSquare, pieceKind(m.promotion))
+}
+
+// LegalMoves returns the moves the side to move can make in the current
+// position, whatever the state of the game
+func (g *Game) LegalMoves() []Move {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.legalMoves()
+}
+
+func (g *Game) legalMoves() []Move {
+	moves := validMovesForPlayer(g.Context.ColorsTurn, g.Board.board, g.Context)
+	for i := range moves {
+		moves[i] = annotate(moves[i], g.Board.board)
+	}
+	return moves
+}
+
+// LegalMovesFrom returns the legal moves of the piece on the square, none
+// if it is not the turn of its color
+func (g *Game) LegalMovesFrom(s Square) []Move {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.legalMovesFrom(s)
+}
+
+func (g *Game) legalMovesFrom(s Square) []Move {
+	var moves []Move
+	for _, m := range g.legalMoves() {
+		if m.FromSquare == s {
+			moves = append(moves, m)
+		}
+	}
+	return moves
+}
+
+// IsLegal reports if the side to move can make the move, a move to the
+// last rank without a promotion piece promotes to a queen
+func (g *Game) IsLegal(move Move) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	promotion := pieceKind(move.promotion)
+	for _, m := range g.legalMovesFrom(move.FromSquare) {
+		if m.ToSquare != move.ToSquare {
+			continue
+		}
+		if pieceKind(m.promotion) == promotion || (promotion == Empty && pieceKind(m.promotion) == Queen) {
+			return true
+		}
+	}
+	return false
 }
 
 // Move gets squares in human readable form, and performs a move
 // error is nil on successful move
 // arguments are two squares : "e2e4"
 func (g *Game) MoveNotation(move Move) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	if g.Context.State != Playing && g.Context.State != Check {
 		return fmt.Errorf("not in playing state")
 	}
 	fromSquare, toSquare := move.FromSquare, move.ToSquare
-	return g.move(fromSquare, toSquare)
+	return g.move(fromSquare, toSquare, pieceKind(move.promotion))
 }
 
 func NewEmptyGame() *Game {
@@ -109,142 +277,208 @@ func NewEmptyGame() *Game {
 	}
 }
 
-func NewGameFromFEN(fen string) *Game {
-	var err error
-	splitted := strings.Split(fen, " ")
-	board := splitted[0]
-	turn := splitted[1]
-	castle := splitted[2]
-	enPassant := splitted[3]
-	halfMove := splitted[4]
-	fullMove := splitted[5]
-	ranks := strings.Split(board, "/")
-
-	finalBoard := map[Square]Piece{}
-	var i, j, row, col, toSkip int
-	var boardIdx Square
-	for i = 0; i < len(ranks); i++ {
-		row = 7 - i
-		col = 0
-		for j = 0; j < len(ranks[i]); j++ {
-			boardIdx = Square(row*8 + col)
-			switch piece := fenToPiece[ranks[i][j]]; {
-			case piece == Empty:
-				toSkip, err = strconv.Atoi(ranks[i][j : j+1])
-				if err != nil {
-					panic(err)
-				}
-				col += toSkip
-			default:
-				finalBoard[boardIdx] = piece
-				col += 1
-			}
-		}
-	}
-	eb := NewEmptyGame()
-	for key, val := range finalBoard {
-		eb.Board.board[key] = val
-	}
-	switch turn {
-	case "w":
-		eb.Context.ColorsTurn = White
-	case "b":
-		eb.Context.ColorsTurn = Black
-	}
-
-	eb.Context.whiteCanCastleLeft = false
-	eb.Context.whiteCanCastleRight = false
-	eb.Context.blackCanCastleRight = false
-	eb.Context.blackCanCastleLeft = false
-	for _, b := range castle {
-		switch b {
-		case 'K':
-			eb.Context.whiteCanCastleRight = true
-		case 'Q':
-			eb.Context.whiteCanCastleLeft = true
-		case 'k':
-			eb.Context.blackCanCastleRight = true
-		case 'q':
-			eb.Context.blackCanCastleLeft = true
-		}
-	}
-
-	switch sq := enPassant; {
-	case sq == "-":
-		eb.Context.enPassantSquare = none
-	default:
-		eb.Context.enPassantSquare = stringToSquare[sq]
-	}
-
-	var halfMoveInt, fullMoveInt int
-	halfMoveInt, err = strconv.Atoi(halfMove)
-	if err != nil {
-		panic(err)
-	}
-	eb.Context.halfMove = halfMoveInt
-	fullMoveInt, err = strconv.Atoi(fullMove)
-	if err != nil {
-		panic(err)
-	}
-	eb.Context.fullMove = fullMoveInt
-	return eb
+// NewGameFromBoard returns a game in the position of the board with the
+// color to move. Kings and rooks on their starting squares may castle.
+// An impossible position is returned as a *FENError.
+func NewGameFromBoard(b *Board, turn Color) (*Game, error) {
+	if turn != White && turn != Black {
+		return nil, fmt.Errorf("%v: %w", turn, ErrFENTurn)
+	}
+	g := NewEmptyGame()
+	g.Board.board = b.board
+	g.Context.ColorsTurn = turn
+	g.Context.whiteCanCastleRight = b.board[e1] == WhiteKing && b.board[h1] == WhiteRook
+	g.Context.whiteCanCastleLeft = b.board[e1] == WhiteKing && b.board[a1] == WhiteRook
+	g.Context.blackCanCastleRight = b.board[e8] == BlackKing && b.board[h8] == BlackRook
+	g.Context.blackCanCastleLeft = b.board[e8] == BlackKing && b.board[a8] == BlackRook
+	if err := validatePosition(g); err != nil {
+		return nil, err
+	}
+	g.initialFEN = g.FenString()
+	return g, nil
 }
 
 func (g *Game) HandleSetMove(move string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	if g.Context.State != Playing && g.Context.State != Check {
 		return ErrNotPlaying
 	}
-	err := g.Move(move)
+	err := g.playMove(move)
 	return err
 }
 
 func (g *Game) HandleSetTime(t time.Duration) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	if !(g.Context.State == Idle) {
 		return ErrAlreadyPlaying
 	}
 	g.StartingTime = t
+	g.timeControls = nil
 	return nil
 }
 
+// HandleSetTimeControl sets the time controls of the players before the
+// game starts, they differ in games with time odds
+func (g *Game) HandleSetTimeControl(white, black TimeControl) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !(g.Context.State == Idle) {
+		return ErrAlreadyPlaying
+	}
+	g.timeControls = map[Color]TimeControl{White: white, Black: black}
+	return nil
+}
+
+// TimeControl returns the time control of the player of color c
+func (g *Game) TimeControl(c Color) TimeControl {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.timeControl(c)
+}
+
+// timeControl returns the time control set for the color, or a single
+// period of StartingTime if none was set, a game without either is untimed
+func (g *Game) timeControl(c Color) TimeControl {
+	if tc, found := g.timeControls[c]; found {
+		return tc
+	}
+	if g.StartingTime == 0 {
+		return TimeControl{}
+	}
+	return NewTimeControl(g.StartingTime, 0)
+}
+
 func (g *Game) HandleResign(uid string) error {
-	var playerInGame bool
-	for _, ps := range g.Players {
-		if (ps.ID == uid) && (ps.Color == White) {
-			won := g.getPlayer(Black)
-			g.Context.WinningPlayer = won
-			playerInGame = true
-		}
-		if (ps.ID == uid) && (ps.Color == Black) {
-			won := g.getPlayer(White)
-			g.Context.WinningPlayer = won
-			playerInGame = true
-		}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Context.State != Playing && g.Context.State != Check {
+		return ErrNotPlaying
 	}
-	if !playerInGame {
-		return fmt.Errorf("ErrNotPlaying")
+	p := g.getPlayerByID(uid)
+	if p == nil {
+		return ErrNotInGame
 	}
+	g.Context.WinningPlayer = g.getPlayer(p.Color.opponent())
 	g.Context.State = Over
+	g.Context.Termination = Resignation
+	g.halt()
+	g.publishGameOver()
+	return nil
+}
+
+// HandleAbandon ends the game in favour of the opponent of a player who
+// left a game in progress
+func (g *Game) HandleAbandon(uid string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Context.State != Playing && g.Context.State != Check {
+		return ErrNotPlaying
+	}
+	p := g.getPlayerByID(uid)
+	if p == nil {
+		return ErrNotInGame
+	}
+	g.Context.WinningPlayer = g.getPlayer(p.Color.opponent())
+	g.Context.State = Over
+	g.Context.Termination = Abandonment
+	g.halt()
+	g.publishGameOver()
+	return nil
+}
+
+// HandleOfferDraw offers the opponent a draw, the offer stands until
+// the opponent accepts it or makes a move
+func (g *Game) HandleOfferDraw(uid string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Context.State != Playing && g.Context.State != Check {
+		return ErrNotPlaying
+	}
+	p := g.getPlayerByID(uid)
+	if p == nil {
+		return ErrNotInGame
+	}
+	g.drawOffer = p.Color
+	g.publishPlayer(DrawOfferEvent, p)
+	return nil
+}
+
+// HandleAcceptDraw accepts a draw offered by the opponent
+func (g *Game) HandleAcceptDraw(uid string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Context.State != Playing && g.Context.State != Check {
+		return ErrNotPlaying
+	}
+	p := g.getPlayerByID(uid)
+	if p == nil {
+		return ErrNotInGame
+	}
+	if g.drawOffer != p.Color.opponent() {
+		return ErrNoDrawOffer
+	}
+	g.drawOffer = Noone
+	g.Context.State = Draw
+	g.Context.Termination = Agreement
+	g.halt()
+	g.publishGameOver()
+	return nil
+}
+
+// HandleClaimDraw lets a seated player claim a draw when the current
+// position has occurred at least three times, or when fifty moves have
+// been played by each player without a pawn move or a capture
+func (g *Game) HandleClaimDraw(uid string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Context.State != Playing && g.Context.State != Check {
+		return ErrNotPlaying
+	}
+	if g.getPlayerByID(uid) == nil {
+		return ErrNotInGame
+	}
+	switch {
+	case g.canClaimThreefold():
+		g.Context.Termination = ThreefoldRepetition
+	case g.canClaimFiftyMoves():
+		g.Context.Termination = FiftyMoveRule
+	default:
+		return ErrNoDrawClaim
+	}
+	g.Context.State = Draw
+	g.halt()
+	g.publishGameOver()
 	return nil
 }
 
 // Enable a player to leave a game before it starts
 func (g *Game) HandleLeave(uid string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	if g.Context.State != Idle {
 		return fmt.Errorf("can't leave in-progress game")
 	}
-	var toDelete int
+	left := g.getPlayerByID(uid)
+	if left == nil {
+		return ErrNotInGame
+	}
 	for i, ps := range g.Players {
-		if ps.ID == uid {
-			toDelete = i
+		if ps == left {
+			g.Players[i] = g.Players[len(g.Players)-1]
+			g.Players[len(g.Players)-1] = &Player{}
+			g.Players = g.Players[:len(g.Players)-1]
+			break
 		}
 	}
-	g.Players[toDelete] = g.Players[len(g.Players)-1]
-	g.Players[len(g.Players)-1] = &Player{}
-	g.Players = g.Players[:len(g.Players)-1]
+	g.publishPlayer(PlayerLeftEvent, left)
 	return nil
 }
 
 func (g *Game) HandlePick(uid string, cstr string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	c := getColor(cstr)
 	for _, ps := range g.Players {
 		if ps.Color == c {
@@ -254,7 +488,9 @@ func (g *Game) HandlePick(uid string, cstr string) error {
 			return ErrAlreadyPlaying
 		}
 	}
-	g.Players = append(g.Players, &Player{ID: uid, Color: c})
+	p := &Player{ID: uid, Color: c}
+	g.Players = append(g.Players, p)
+	g.publishPlayer(PlayerJoinedEvent, p)
 	return nil
 }
 
@@ -268,82 +504,27 @@ func getColor(cstr string) Color {
 	panic(fmt.Errorf("no such color: %s\n", cstr))
 }
 
-func GameFromPGN(reader io.Reader) *Game {
-	g := NewGame()
-	moves, err := pgnParse(reader)
+// GameFromPGN reads the first game of the PGN stream, errors in the game
+// are returned as a *PGNError
+func GameFromPGN(reader io.Reader) (*Game, error) {
+	pgn, err := ParsePGN(reader)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-
-	g.Moves = moves
-
-	return g
+	return pgn.Game()
 }
 
-func (g *Game) FenString() string {
-	var cnt int
-	var board string
-	var sq Square
-	for i := 7; i >= 0; i-- {
-		cnt = 0
-		for j := 0; j < 8; j++ {
-			sq = Square(i*8 + j)
-
-			switch p := g.Board.board[sq]; {
-			case p == Empty:
-				cnt += 1
-			default:
-				if cnt > 0 {
-					board += strconv.Itoa(cnt)
-				}
-				cnt = 0
-				board += pieceToFen[p]
-			}
-			if j == 7 {
-				if cnt == 0 {
-					board += "/"
-					continue
-				}
-				board += strconv.Itoa(cnt) + "/"
-				cnt = 0
-			}
-		}
-	}
-	board = strings.TrimSuffix(board, "/")
-
-	toMove := playerToFen[g.Context.ColorsTurn]
-
-	var castle string
-	if g.Context.whiteCanCastleRight {
-		castle += pieceToFen[WhiteKing]
-	}
-	if g.Context.whiteCanCastleLeft {
-		castle += pieceToFen[WhiteQueen]
-	}
-	if g.Context.blackCanCastleRight {
-		castle += pieceToFen[BlackKing]
-	}
-	if g.Context.whiteCanCastleRight {
-		castle += pieceToFen[BlackQueen]
-	}
-
-	if castle == "" {
-		castle = "-"
+// move performs the move between the two squares, promo is the kind of
+// piece to promote to, Queen if Empty
+func (g *Game) move(fromSquare, toSquare Square, promo Piece) error {
+	// The player may have run out of time already
+	if g.outOfTime() {
+		g.stopClock()
+		g.flag()
+		g.publishGameOver()
+		return ErrOutOfTime
 	}
 
-	var enpassant string
-	if g.Context.enPassantSquare >= a1 {
-		enpassant = g.Context.enPassantSquare.String()
-	} else {
-		enpassant = "-"
-	}
-	halfMove := strconv.Itoa(g.Context.halfMove)
-	fullMove := strconv.Itoa(g.Context.fullMove)
-	return fmt.Sprintf("%s %s %s %s %s %s", board, toMove, castle, enpassant, halfMove, fullMove)
-}
-
-func (g *Game) move(fromSquare, toSquare Square) error {
-
 	var opponent Color
 	switch g.Context.ColorsTurn {
 	case White:
@@ -367,38 +548,69 @@ func (g *Game) move(fromSquare, toSquare Square) error {
 
 	//todo: replace with function thate uses chess algebraic notation
 	var m Move
+	var isPromotion, found bool
 	for _, move := range availMoves {
-		if move.FromSquare == fromSquare && move.ToSquare == toSquare {
+		if move.FromSquare != fromSquare || move.ToSquare != toSquare {
+			continue
+		}
+		if move.promotion != Empty {
+			isPromotion = true
+		}
+		if pieceKind(move.promotion) == promo || (promo == Empty && pieceKind(move.promotion) == Queen) {
 			m = move
+			found = true
 		}
 	}
-	if m.ToSquare == none {
-		return fmt.Errorf("target square %s is 'none'\n", squareToString[toSquare])
+	if promo != Empty && !isPromotion {
+		return fmt.Errorf("%s%s is not a promotion\n", squareToString[fromSquare], squareToString[toSquare])
+	}
+	if !found {
+		return fmt.Errorf("%s%s can't promote to %s\n", squareToString[fromSquare], squareToString[toSquare], promo)
 	}
 
-	// Commit the move to the board, update timers
-	g.Board.board = makeMove(m, g.Board.board)
-	p := g.getPlayer(g.Context.ColorsTurn)
-	p.moves = append(p.moves, m)
-	g.Moves = append(g.Moves, &m)
+	g.history = append(g.history, g.snapshot())
+	g.redo = nil
 
-	opponentsKing := getKingSquareMust(opponent, g.Board.board)
-	if inCheck(opponentsKing, g.Board.board) {
-		g.Context.State = Check
-	} else {
-		g.Context.State = Playing
+	// The starting position counts towards repetitions as well
+	if len(g.positions) == 0 {
+		g.positions = append(g.positions, g.position())
 	}
 
-	if isCheckMated(opponentsKing, g.Board.board) {
-		g.Context.State = CheckMate
-		g.Context.WinningPlayer = p
-		return nil
+	// A pending draw offer is declined by moving
+	if g.drawOffer == opponent {
+		g.drawOffer = Noone
 	}
+	// And so is a takeback request, which a move by the player withdraws
+	g.takebackRequest = Noone
 
-	if isDraw(opponent, g.Board.board, g.Context) {
-		g.Context.State = Draw
-		return nil
+	// Notation depends on the position before the move
+	m = annotate(m, g.Board.board)
+	m.san = san(m, g.Board.board, g.Context)
+
+	// Commit the move to the board, update timers
+	g.Board.board = makeMove(m, g.Board.board)
+	p := g.getPlayer(g.Context.ColorsTurn)
+	if g.clockRunning() {
+		elapsed := g.stopClock()
+		bonus := g.timeControl(p.Color).bonus(len(p.moves)+1, elapsed)
+		p.TimeLeft += bonus
+		g.history[len(g.history)-1].bonus = bonus
 	}
+	m.timeStamp = g.turnStartedAt
+	p.moves = append(p.moves, m)
+	g.Moves = append(g.Moves, &m)
+	if g.moved == nil {
+		g.moved = map[Color]bool{}
+	}
+	g.moved[p.Color] = true
+	// Subscribers learn about the move once its consequences are known,
+	// by then the clocks are stopped if the move ended the game
+	defer g.publishMove(m, p)
+	defer func() {
+		if g.Context.State == CheckMate || g.Context.State == Draw {
+			g.halt()
+		}
+	}()
 
 	// Invalidate castling rules if move prevents castling
 	g.abortCastling(m)
@@ -412,13 +624,14 @@ func (g *Game) move(fromSquare, toSquare Square) error {
 	}
 
 	//Increment half move if this was not a pawn move and not a capture
-	isPawnMove := false
+	resetsHalfMove := false
 	for _, moveType := range m.moveTypes {
-		if moveType == PawnMove {
-			isPawnMove = true
+		switch moveType {
+		case PawnMove, Capture, CapturePromotion, CaptureEnPassant:
+			resetsHalfMove = true
 		}
 	}
-	if isPawnMove {
+	if resetsHalfMove {
 		g.Context.halfMove = 0
 	} else {
 		g.Context.halfMove += 1
@@ -426,21 +639,65 @@ func (g *Game) move(fromSquare, toSquare Square) error {
 
 	// Switch next turn to other player
 	g.switchTurn()
+	g.positions = append(g.positions, g.position())
+
+	opponentsKing := getKingSquareMust(opponent, g.Board.board)
+	if inCheck(opponentsKing, g.Board.board) {
+		g.Context.State = Check
+	} else {
+		g.Context.State = Playing
+	}
+
+	if isCheckMated(opponentsKing, g.Board.board) {
+		g.Context.State = CheckMate
+		g.Context.Termination = Checkmated
+		g.Context.WinningPlayer = p
+		return nil
+	}
+
+	if isDraw(opponent, g.Board.board, g.Context) {
+		g.Context.State = Draw
+		g.Context.Termination = drawTermination(opponent, g.Board.board, g.Context)
+		return nil
+	}
+
+	if g.repetitions() >= 5 {
+		g.Context.State = Draw
+		g.Context.Termination = FivefoldRepetition
+		return nil
+	}
+
+	if g.Context.halfMove >= seventyFiveMoveRule {
+		g.Context.State = Draw
+		g.Context.Termination = SeventyFiveMoveRule
+	}
 	return nil
 }
 
 func (g *Game) getEnPassantSquare(m Move) Square {
+	return enPassantSquare(m, g.Board.board)
+}
+
+// enPassantSquare returns the square passed by a pawn moving two squares
+// with move m, if the opponent has a pawn to capture it with. b is the
+// board after the move.
+func enPassantSquare(m Move, b [64]Piece) Square {
 	if m.piece != WhitePawn && m.piece != BlackPawn {
-		g.Context.enPassantSquare = none
 		return none
 	}
+	var ep Square
 	if m.FromSquare.rank() == 2 && m.ToSquare.rank() == 4 && m.piece == WhitePawn {
-		return m.FromSquare + 8
+		ep = m.FromSquare + 8
 	} else if m.FromSquare.rank() == 7 && m.ToSquare.rank() == 5 && m.piece == BlackPawn {
-		return m.FromSquare - 8
+		ep = m.FromSquare - 8
 	} else {
 		return none
 	}
+	// Only set if the opponent has a pawn to capture with
+	if !canCaptureEnPassant(ep, b, m.Color.opponent()) {
+		return none
+	}
+	return ep
 }
 
 func (g *Game) switchTurn() {
@@ -484,12 +741,29 @@ func (g *Game) abortCastling(m Move) {
 }
 
 func (g *Game) getPlayer(c Color) *Player {
+	if p := g.findPlayer(c); p != nil {
+		return p
+	}
+	panic(fmt.Sprintf("no player with Color %s in game", c.String()))
+}
+
+// findPlayer returns the player of color c, nil if there is none
+func (g *Game) findPlayer(c Color) *Player {
 	for _, p := range g.Players {
 		if p.Color == c {
 			return p
 		}
 	}
-	panic(fmt.Sprintf("no player with Color %s in game", c.String()))
+	return nil
+}
+
+func (g *Game) getPlayerByID(uid string) *Player {
+	for _, p := range g.Players {
+		if p.ID == uid {
+			return p
+		}
+	}
+	return nil
 }
 
 func (g *Game) getOpponent(p *Player) *Player {
@@ -539,6 +813,7 @@ func NewGame() *Game {
 			State:               Idle,
 			ColorsTurn:          White,
 			WinningPlayer:       nil,
+			enPassantSquare:     none,
 			whiteCanCastleRight: true,
 			whiteCanCastleLeft:  true,
 			blackCanCastleRight: true,
@@ -12,38 +12,285 @@ func TestGame_Start(t *testing.T) {
 		game      *Game
 		moves     []string
 		wantScore string
+		wantState State
 	}{
 		{
 			name:      "white lose on time",
 			game:      NewGame(),
 			moves:     []string{},
 			wantScore: "0 - 1",
+			wantState: Over,
 		},
 		{
 			name:      "black lose on time after white makes a move",
 			game:      NewGame(),
 			moves:     []string{"e2e4"},
 			wantScore: "1 - 0",
+			wantState: Over,
+		},
+		{
+			name:      "white runs out of time against a lone king",
+			game:      NewGameFromFEN("4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"),
+			moves:     []string{},
+			wantScore: "1/2 - 1/2",
+			wantState: Draw,
+		},
+		{
+			name:      "white runs out of time against a king and a bishop",
+			game:      NewGameFromFEN("4kb2/8/8/8/8/8/8/4K3 w - - 0 1"),
+			moves:     []string{},
+			wantScore: "1/2 - 1/2",
+			wantState: Draw,
 		},
 	}
 	for _, tt := range tests {
-		tt.game.Players = []*Player{
-			{Color: Black, ID: "black"},
-			{Color: White, ID: "white"},
+		t.Run(tt.name, func(t *testing.T) {
+			clock := useFakeClock()
+			defer clock.restore()
+
+			tt.game.Players = []*Player{
+				{Color: Black, ID: "black"},
+				{Color: White, ID: "white"},
+			}
+			tt.game.StartingTime = time.Minute
+			cleanup := tt.game.Start()
+			defer cleanup()
+			// Make all Moves:
+
+			for _, m := range tt.moves {
+				clock.advance(time.Second)
+				err := tt.game.Move(m)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			clock.advance(time.Minute)
+			// The ticker notices the flag fall
+			assert.Eventually(t, func() bool {
+				return tt.game.CurrentContext().State != Playing
+			}, time.Second, gameUpdateInterval/10)
+			ctx := tt.game.CurrentContext()
+			assert.Equal(t, tt.wantScore, ctx.Score(), "Score should be same")
+			assert.Equal(t, tt.wantState, ctx.State, "State should be same")
+			assert.Equal(t, Timeout, ctx.Termination, "Termination should be same")
+		})
+	}
+}
+
+// TestGame_Concurrent plays moves while the clock is ticking and other
+// goroutines look at the game, run with -race to check access to the
+// game is serialized
+func TestGame_Concurrent(t *testing.T) {
+	g := NewGame()
+	g.Players = []*Player{
+		{Color: White, ID: "white"},
+		{Color: Black, ID: "black"},
+	}
+	g.StartingTime = time.Hour
+	start := g.FenString()
+	cleanup := g.Start()
+	defer cleanup()
+
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		for i := 0; i < 2*int(gameUpdateInterval/time.Millisecond)/5; i++ {
+			for _, m := range []string{"e2e4", "e7e5", "Nf3"} {
+				if err := g.PlayMove(m); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			assert.Nil(t, g.HandleOfferDraw("black"))
+			assert.Nil(t, g.Undo())
+			assert.Nil(t, g.Undo())
+			assert.Nil(t, g.Undo())
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			assert.Equal(t, Playing, g.CurrentContext().State)
+			assert.Equal(t, start, g.FenString())
+			assert.True(t, g.TimeLeft(White) < time.Hour)
+			return
+		default:
+			g.CurrentContext()
+			g.TimeLeft(White)
+			g.LegalMoves()
+			g.FenString()
+			g.PGN()
 		}
-		tt.game.startedAt = 0
-		tt.game.StartingTime = gameUpdateInterval / 2
-		cleanup := tt.game.Start()
-		// Make all Moves:
+	}
+}
 
+func TestTermination(t *testing.T) {
+	tests := []struct {
+		name            string
+		fen             string
+		moves           []string
+		handle          func(g *Game) error
+		wantState       State
+		wantTermination Termination
+		wantResult      Result
+	}{
+		{
+			name:            "checkmate",
+			fen:             "4k3/8/4K3/8/8/8/8/R7 w - - 0 1",
+			moves:           []string{"a1a8"},
+			wantState:       CheckMate,
+			wantTermination: Checkmated,
+			wantResult:      WhiteWon,
+		},
+		{
+			name:            "stalemate",
+			fen:             "7k/8/5K2/8/8/8/8/6Q1 w - - 0 1",
+			moves:           []string{"g1g6"},
+			wantState:       Draw,
+			wantTermination: Stalemate,
+			wantResult:      Drawn,
+		},
+		{
+			name:            "resignation",
+			fen:             "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			handle:          func(g *Game) error { return g.HandleResign("white") },
+			wantState:       Over,
+			wantTermination: Resignation,
+			wantResult:      BlackWon,
+		},
+		{
+			name: "agreement",
+			fen:  "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			handle: func(g *Game) error {
+				if err := g.HandleOfferDraw("black"); err != nil {
+					return err
+				}
+				return g.HandleAcceptDraw("white")
+			},
+			wantState:       Draw,
+			wantTermination: Agreement,
+			wantResult:      Drawn,
+		},
+		{
+			name:            "abandonment",
+			fen:             "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			handle:          func(g *Game) error { return g.HandleAbandon("black") },
+			wantState:       Over,
+			wantTermination: Abandonment,
+			wantResult:      WhiteWon,
+		},
+	}
+	for _, tt := range tests {
+		g := NewGameFromFEN(tt.fen)
+		g.Context.State = Playing
+		g.Players = []*Player{
+			{Color: White, ID: "white"},
+			{Color: Black, ID: "black"},
+		}
 		for _, m := range tt.moves {
-			err := tt.game.Move(m)
+			err := g.Move(m)
 			if err != nil {
 				t.Fatal(err)
 			}
 		}
-		defer cleanup()
-		time.Sleep(gameUpdateInterval + 5*time.Millisecond)
-		assert.Equal(t, tt.wantScore, tt.game.Context.Score(), "Score should be same")
+		if tt.handle != nil {
+			err := tt.handle(g)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		assert.Equal(t, tt.wantState, g.Context.State, tt.name)
+		assert.Equal(t, tt.wantTermination, g.Context.Termination, tt.name)
+		assert.Equal(t, tt.wantResult, g.Context.Result(), tt.name)
+	}
+}
+
+func TestDrawOffer(t *testing.T) {
+	g := NewGame()
+	g.Context.State = Playing
+	g.Players = []*Player{
+		{Color: White, ID: "white"},
+		{Color: Black, ID: "black"},
+	}
+	assert.Equal(t, ErrNoDrawOffer, g.HandleAcceptDraw("black"))
+
+	// The offer survives the move of the player offering
+	assert.Nil(t, g.HandleOfferDraw("white"))
+	assert.Nil(t, g.Move("e2e4"))
+	assert.Equal(t, ErrNoDrawOffer, g.HandleAcceptDraw("white"))
+
+	// And is declined by a move of the opponent
+	assert.Nil(t, g.Move("e7e5"))
+	assert.Equal(t, ErrNoDrawOffer, g.HandleAcceptDraw("black"))
+	assert.Equal(t, Playing, g.Context.State)
+}
+
+func TestLegalMoves(t *testing.T) {
+	table := []struct {
+		fen          string
+		from         Square
+		expectedAll  int
+		expectedFrom []string
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", g1, 20, []string{"g1f3", "g1h3"}},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", e7, 20, nil},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", e4, 20, nil},
+		{"k7/3P4/8/8/8/8/8/4K3 w - - 0 1", d7, 9, []string{"d7d8b", "d7d8n", "d7d8r", "d7d8q"}},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", e8, 26, []string{"e8d8", "e8f8", "e8d7", "e8e7", "e8f7", "e8g8", "e8c8"}},
+		{"rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3", e1, 0, nil},
+	}
+
+	for _, row := range table {
+		g := NewGameFromFEN(row.fen)
+		assert.Len(t, g.LegalMoves(), row.expectedAll, row.fen)
+		var got []string
+		for _, m := range g.LegalMovesFrom(row.from) {
+			got = append(got, m.UCI())
+		}
+		assert.ElementsMatch(t, row.expectedFrom, got, row.fen)
+	}
+}
+
+func TestLegalMovesDetails(t *testing.T) {
+	g := NewGameFromFEN("k1r5/3P4/8/8/8/8/8/4K3 w - - 0 1")
+	var checks, captures int
+	for _, m := range g.LegalMovesFrom(d7) {
+		assert.Equal(t, WhitePawn, m.Piece())
+		assert.NotEqual(t, Empty, m.Promotion())
+		if m.IsCapture() {
+			captures++
+			assert.Equal(t, BlackRook, m.Captured())
+		}
+		if m.IsCheck() {
+			checks++
+		}
+	}
+	// Only taking the rook opens the rank for a queen or rook to check
+	assert.Equal(t, 4, captures)
+	assert.Equal(t, 2, checks)
+}
+
+func TestIsLegal(t *testing.T) {
+	g := NewGameFromFEN("k7/3P4/8/8/8/8/8/4K3 w - - 0 1")
+	table := []struct {
+		move     Move
+		expected bool
+	}{
+		{Move{FromSquare: d7, ToSquare: d8}, true},
+		{Move{FromSquare: d7, ToSquare: d8, promotion: WhiteKnight}, true},
+		{Move{FromSquare: d7, ToSquare: d8, promotion: Knight}, true},
+		{Move{FromSquare: d7, ToSquare: d8, promotion: King}, false},
+		{Move{FromSquare: e1, ToSquare: e2}, true},
+		{Move{FromSquare: e1, ToSquare: e3}, false},
+		{Move{FromSquare: a8, ToSquare: a7}, false},
+		{Move{FromSquare: a1, ToSquare: a2}, false},
+	}
+	for _, row := range table {
+		assert.Equal(t, row.expected, g.IsLegal(row.move), row.move.UCI())
+	}
+	for _, m := range g.LegalMoves() {
+		assert.True(t, g.IsLegal(m), m.UCI())
 	}
 }
@@ -15,6 +15,21 @@ func (s Square) rank() Square {
 	return s/8 + 1
 }
 
+func (c Color) opponent() Color {
+	switch c {
+	case White:
+		return Black
+	case Black:
+		return White
+	}
+	return Noone
+}
+
+// isLight reports if the square is a light square, a1 is dark
+func (s Square) isLight() bool {
+	return (s.row()+s.col())%2 == 1
+}
+
 func getKingSquareMust(p Color, b [64]Piece) Square {
 	var king Piece
 	switch p {
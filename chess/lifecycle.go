@@ -0,0 +1,104 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrTooLateToAbort = errors.New("both players have moved")
+
+// TransitionError is returned when the game can't go through a transition
+// of its lifecycle in its current state
+type TransitionError struct {
+	Transition string // "pause", "resume", "abort", "undo" or "redo"
+	State      State  // State the game was in
+	Err        error  // Why the transition is not allowed, if not the state
+}
+
+func (e *TransitionError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("can't %s game: %s", e.Transition, e.Err)
+	}
+	return fmt.Sprintf("can't %s game in state %s", e.Transition, e.State)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return e.Err
+}
+
+// Pause stops the clocks of a game being played, no moves can be made
+// until it is resumed
+func (g *Game) Pause() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Context.State != Playing && g.Context.State != Check {
+		return &TransitionError{Transition: "pause", State: g.Context.State}
+	}
+	// The time spent so far is charged with the rest of the turn
+	g.turnSpent = g.turnTime()
+	g.pausedState = g.Context.State
+	g.Context.State = Paused
+	g.publish(Event{Type: PauseEvent})
+	return nil
+}
+
+// Resume continues a paused game, the clock of the side to move starts
+// running again
+func (g *Game) Resume() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Context.State != Paused {
+		return &TransitionError{Transition: "resume", State: g.Context.State}
+	}
+	g.Context.State = g.pausedState
+	if g.timed {
+		g.turnStartedAt = timeNow()
+	}
+	g.publish(Event{Type: ResumeEvent})
+	return nil
+}
+
+// Abort stops a game without a result, before it started or as long as
+// not both players have made a move. A move taken back still counts.
+func (g *Game) Abort() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	switch g.Context.State {
+	case Idle, Playing, Check, Paused:
+	default:
+		return &TransitionError{Transition: "abort", State: g.Context.State}
+	}
+	if g.moved[White] && g.moved[Black] {
+		return &TransitionError{Transition: "abort", State: g.Context.State, Err: ErrTooLateToAbort}
+	}
+	g.abort()
+	return nil
+}
+
+// End stops the game, a game still in progress is aborted whatever the
+// moves made, a game that is over keeps its result
+func (g *Game) End() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	switch g.Context.State {
+	case Idle, Playing, Check, Paused:
+		g.abort()
+	}
+	g.halt()
+}
+
+func (g *Game) abort() {
+	if g.timed {
+		g.stopClock()
+	}
+	g.Context.State = Aborted
+	g.halt()
+	g.publishGameOver()
+}
+
+// halt stops the goroutine started by Start, if any
+func (g *Game) halt() {
+	if g.stop != nil {
+		g.stop()
+	}
+}
@@ -0,0 +1,229 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newStartedGame(fen string) (*Game, func()) {
+	g := NewGameFromFEN(fen)
+	g.Players = []*Player{{Color: White, ID: "white"}, {Color: Black, ID: "black"}}
+	g.StartingTime = time.Minute
+	return g, g.Start()
+}
+
+func TestPauseResume(t *testing.T) {
+	clock := useFakeClock()
+	defer clock.restore()
+	g, cleanup := newStartedGame("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+	defer cleanup()
+
+	clock.advance(5 * time.Second)
+	assert.Nil(t, g.Pause())
+	assert.Equal(t, Paused, g.CurrentContext().State)
+	assert.NotNil(t, g.Move("e2e4"))
+
+	// The clocks stand still, and no flag falls
+	clock.advance(2 * time.Minute)
+	g.mu.Lock()
+	g.tick()
+	g.mu.Unlock()
+	assert.Equal(t, 55*time.Second, g.TimeLeft(White))
+	assert.Equal(t, Paused, g.CurrentContext().State)
+
+	assert.Nil(t, g.Resume())
+	assert.Equal(t, Playing, g.CurrentContext().State)
+	clock.advance(5 * time.Second)
+	assert.Equal(t, 50*time.Second, g.TimeLeft(White))
+	assert.Nil(t, g.Move("e2e4"))
+	assert.Equal(t, time.Minute, g.TimeLeft(Black))
+
+	var err *TransitionError
+	assert.True(t, errors.As(g.Resume(), &err))
+	assert.Equal(t, "resume", err.Transition)
+	assert.Equal(t, Playing, err.State)
+	assert.Equal(t, "can't resume game in state Playing", err.Error())
+}
+
+func TestPauseDelay(t *testing.T) {
+	// The delay is applied once to the whole turn, however often it is
+	// paused
+	for _, tag := range []string{"60d5", "60b5"} {
+		clock := useFakeClock()
+		tc, _ := ParseTimeControl(tag)
+		g := NewGame()
+		g.Players = []*Player{{Color: White}, {Color: Black}}
+		assert.Nil(t, g.HandleSetTimeControl(tc, tc))
+		cleanup := g.Start()
+
+		clock.advance(4 * time.Second)
+		assert.Nil(t, g.Pause())
+		clock.advance(time.Minute)
+		assert.Nil(t, g.Resume())
+		clock.advance(4 * time.Second)
+		assert.Nil(t, g.Move("e2e4"))
+		assert.Equal(t, 57*time.Second, g.TimeLeft(White), tag)
+
+		cleanup()
+		clock.restore()
+	}
+}
+
+func TestPauseInCheck(t *testing.T) {
+	g, cleanup := newStartedGame("4k3/8/8/8/8/8/8/R3K3 w - - 0 1")
+	defer cleanup()
+
+	assert.Nil(t, g.Move("a1a8"))
+	assert.Nil(t, g.Pause())
+	var err *TransitionError
+	assert.True(t, errors.As(g.Pause(), &err))
+	assert.Equal(t, Paused, err.State)
+	assert.Nil(t, g.Resume())
+	assert.Equal(t, Check, g.CurrentContext().State)
+}
+
+// halted reports whether the goroutine running the clocks has returned
+func halted(g *Game) bool {
+	g.mu.Lock()
+	stopped := g.stopped
+	g.mu.Unlock()
+	select {
+	case <-stopped:
+		return true
+	case <-time.After(time.Second):
+		return false
+	}
+}
+
+func TestHaltWhenOver(t *testing.T) {
+	const start = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	clock := useFakeClock()
+	defer clock.restore()
+	table := []struct {
+		name string
+		end  func(g *Game) error
+	}{
+		{"checkmate", func(g *Game) error {
+			for _, m := range []string{"f2f3", "e7e5", "g2g4", "d8h4"} {
+				if err := g.Move(m); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{"timeout", func(g *Game) error {
+			clock.advance(2 * time.Minute)
+			if err := g.Move("e2e4"); err != ErrOutOfTime {
+				return fmt.Errorf("got: %v, expected: %v", err, ErrOutOfTime)
+			}
+			return nil
+		}},
+		{"resignation", func(g *Game) error { return g.HandleResign("white") }},
+		{"abandonment", func(g *Game) error { return g.HandleAbandon("black") }},
+		{"agreement", func(g *Game) error {
+			if err := g.HandleOfferDraw("white"); err != nil {
+				return err
+			}
+			return g.HandleAcceptDraw("black")
+		}},
+	}
+	for _, row := range table {
+		g, cleanup := newStartedGame(start)
+		assert.Nil(t, row.end(g), row.name)
+		assert.True(t, halted(g), row.name)
+		cleanup()
+	}
+
+	// Taking back the move that ended the game starts the clocks again
+	g, cleanup := newStartedGame(start)
+	defer cleanup()
+	for _, m := range []string{"f2f3", "e7e5", "g2g4", "d8h4"} {
+		assert.Nil(t, g.Move(m))
+	}
+	assert.True(t, halted(g))
+	assert.Nil(t, g.Undo())
+	g.mu.Lock()
+	stopped := g.stopped
+	g.mu.Unlock()
+	select {
+	case <-stopped:
+		t.Error("the clocks are not running after the takeback")
+	case <-time.After(2 * gameUpdateInterval):
+	}
+}
+
+func TestAbort(t *testing.T) {
+	table := []struct {
+		moves []string
+		err   error
+	}{
+		{nil, nil},
+		{[]string{"e2e4"}, nil},
+		{[]string{"e2e4", "e7e5"}, ErrTooLateToAbort},
+		// Taking the moves back doesn't make it early again
+		{[]string{"e2e4", "e7e5", "undo", "undo"}, ErrTooLateToAbort},
+	}
+	for _, row := range table {
+		g, cleanup := newStartedGame("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+		for _, m := range row.moves {
+			if m == "undo" {
+				assert.Nil(t, g.Undo())
+				continue
+			}
+			assert.Nil(t, g.Move(m))
+		}
+		err := g.Abort()
+		if row.err != nil {
+			assert.True(t, errors.Is(err, row.err), row.moves)
+			assert.Equal(t, Playing, g.CurrentContext().State)
+			cleanup()
+			continue
+		}
+		assert.Nil(t, err, row.moves)
+		ctx := g.CurrentContext()
+		assert.Equal(t, Aborted, ctx.State)
+		assert.Equal(t, NoResult, ctx.Result())
+		assert.NotNil(t, g.Move("d2d4"))
+		cleanup()
+	}
+
+	// A game that hasn't started can be aborted, one that is over can't
+	g := NewGame()
+	assert.Nil(t, g.Abort())
+	var err *TransitionError
+	assert.True(t, errors.As(g.Abort(), &err))
+	assert.Equal(t, Aborted, err.State)
+	assert.Equal(t, "abort", err.Transition)
+}
+
+func TestEnd(t *testing.T) {
+	g, cleanup := newStartedGame("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+	events := g.Subscribe()
+	assert.Nil(t, g.HandleResign("black"))
+	// Returns once the clock goroutine is gone, and can be called again
+	cleanup()
+	cleanup()
+	assert.Equal(t, Over, g.CurrentContext().State)
+	assert.Equal(t, []EventType{GameOverEvent}, eventTypes(received(events)))
+
+	// A game in progress is aborted, whatever the moves made
+	g, cleanup = newStartedGame("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+	assert.Nil(t, g.Move("e2e4"))
+	assert.Nil(t, g.Move("e7e5"))
+	assert.Nil(t, g.Pause())
+	var err *TransitionError
+	assert.True(t, errors.As(g.Undo(), &err))
+	assert.Equal(t, "undo", err.Transition)
+	cleanup()
+	assert.Equal(t, Aborted, g.CurrentContext().State)
+	assert.True(t, errors.As(g.Undo(), &err))
+	assert.Equal(t, Aborted, err.State)
+
+	// Starting again is not possible
+	g.Start()()
+	assert.Equal(t, Aborted, g.CurrentContext().State)
+}
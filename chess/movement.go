@@ -12,10 +12,13 @@ type Move struct {
 	FromSquare     Square
 	ToSquare       Square
 	piece          Piece
+	promotion      Piece           // Piece the pawn promotes to, Empty if none
+	captured       Piece           // Piece taken by the move, set by annotate
 	piecePositions []piecePosition // Resulting pieces in each square
 	moveTypes      []MovementType
 	reverseMove    *Move
 	timeStamp      int64
+	san            string // Notation of the move as played, set by Game
 }
 
 func (m Move) String() string {
@@ -29,6 +32,81 @@ func (m Move) String() string {
 	return fmt.Sprintf("move: \"%s%s\", pp's: %s, movement types: %s", m.FromSquare, m.ToSquare, pp, mts)
 }
 
+// Piece returns the piece moved, a pawn for promotions
+func (m Move) Piece() Piece {
+	return m.piece
+}
+
+// Captured returns the piece taken by a move played or listed by a Game,
+// Empty if none
+func (m Move) Captured() Piece {
+	return m.captured
+}
+
+// Promotion returns the piece a pawn promotes to, Empty if none
+func (m Move) Promotion() Piece {
+	return m.promotion
+}
+
+func (m Move) IsCapture() bool {
+	return m.hasType(Capture) || m.hasType(CapturePromotion) || m.hasType(CaptureEnPassant)
+}
+
+func (m Move) IsCastle() bool {
+	return m.hasType(Castle)
+}
+
+func (m Move) IsEnPassant() bool {
+	return m.hasType(CaptureEnPassant)
+}
+
+// IsCheck if a move played or listed by a Game checks the opponent
+func (m Move) IsCheck() bool {
+	return m.hasType(CheckMove)
+}
+
+// Flags returns the kinds of movement the move consists of
+func (m Move) Flags() []MovementType {
+	return append([]MovementType(nil), m.moveTypes...)
+}
+
+// UCI returns the move in the coordinate notation of the Universal Chess
+// Interface, "e2e4", "e7e8q", castling as the king's move, "e1g1"
+func (m Move) UCI() string {
+	return m.FromSquare.String() + m.ToSquare.String() + promotionToString[pieceKind(m.promotion)]
+}
+
+func (m Move) hasType(mt MovementType) bool {
+	for _, each := range m.moveTypes {
+		if each == mt {
+			return true
+		}
+	}
+	return false
+}
+
+// annotate sets the piece captured by the move and whether it checks, as
+// played in the position of board
+func annotate(m Move, board [64]Piece) Move {
+	switch {
+	case m.IsEnPassant():
+		m.captured = WhitePawn
+		if m.Color == White {
+			m.captured = BlackPawn
+		}
+	case m.IsCastle():
+		m.captured = Empty
+	default:
+		m.captured = board[m.ToSquare]
+	}
+	after := makeMove(m, board)
+	if inCheck(getKingSquareMust(m.Color.opponent(), after), after) && !m.IsCheck() {
+		// Promotions share their slice of types, don't append to it
+		m.moveTypes = append(m.moveTypes[:len(m.moveTypes):len(m.moveTypes)], CheckMove)
+	}
+	return m
+}
+
 func validMovesForSquare(fromSquare Square, board [64]Piece, ctx Context) []Move {
 	var moves []Move
 	if fromSquare == none {
@@ -81,7 +159,6 @@ func validMovesForPlayer(player Color, board [64]Piece, ctx Context) []Move {
 			moves = append(moves, validMovesForSquare(square, board, ctx)...)
 		}
 	}
-	moves = cleanMovesInCheck(moves, board, player)
 	return moves
 }
 
@@ -100,13 +177,15 @@ func getPieceSquares(piece Piece, board [64]Piece) []Square {
 //remove Moves that result in player being in check
 func cleanMovesInCheck(m []Move, b [64]Piece, p Color) []Move {
 	var cleanMoves []Move
+	var tmp [64]Piece
 	for _, move := range m {
-		b = makeMove(move, b)
-		ks := getKingSquareMust(p, b)
-		if !inCheck(ks, b) {
+		// Try the move on a copy, reverse moves of pawn captures don't
+		// restore the captured piece
+		tmp = makeMove(move, b)
+		ks := getKingSquareMust(p, tmp)
+		if !inCheck(ks, tmp) {
 			cleanMoves = append(cleanMoves, move)
 		}
-		b = makeMove(*move.reverseMove, b)
 	}
 	return cleanMoves
 }
@@ -490,13 +569,13 @@ func castleMoves(kingSquare Square, b [64]Piece, ctx Context) []Move {
 	}
 	isBlack = !isWhite
 
+	// The king can't castle out of, through or into check, on the long
+	// side the rook passes the b-file square, which may be attacked
 	if canCastleRight {
-		for _, p := range squaresWithoutKing(opponent, b) {
-			for _, move := range validMovesForSquare(p, b, ctx) {
-				if inSquares(move.ToSquare, append(shortCastleSquares, kingSquare)) {
-					canCastleRight = false
-					break
-				}
+		for _, sq := range []Square{kingSquare, shortCastleSquares[0], shortCastleSquares[1]} {
+			if isAttacked(sq, opponent, b) {
+				canCastleRight = false
+				break
 			}
 		}
 		if !allEmpty(shortCastleSquares, b) {
@@ -504,12 +583,10 @@ func castleMoves(kingSquare Square, b [64]Piece, ctx Context) []Move {
 		}
 	}
 	if canCastleLeft {
-		for _, p := range squaresWithoutKing(opponent, b) {
-			for _, move := range validMovesForSquare(p, b, ctx) {
-				if inSquares(move.ToSquare, append(longCastleSquares, kingSquare)) {
-					canCastleLeft = false
-					break
-				}
+		for _, sq := range []Square{kingSquare, longCastleSquares[0], longCastleSquares[1]} {
+			if isAttacked(sq, opponent, b) {
+				canCastleLeft = false
+				break
 			}
 		}
 		if !allEmpty(longCastleSquares, b) {
@@ -1025,6 +1102,8 @@ func createPawnPromotionMoves(c Color, f, t Square, targetPiece Piece, mt []Move
 	for _, piece := range []Piece{bishop, knight, rook, queen} {
 		moves = append(moves, Move{
 			Color:      c,
+			piece:      pawn,
+			promotion:  piece,
 			FromSquare: f,
 			ToSquare:   t,
 			piecePositions: []piecePosition{
@@ -1062,6 +1141,7 @@ func createPawnPromotionMove(board [64]Piece, f, t Square, promoPiece Piece, mt
 	move := Move{
 		Color:      pieceToColor(pawn),
 		piece:      pawn,
+		promotion:  promoPiece,
 		FromSquare: f,
 		ToSquare:   t,
 		piecePositions: []piecePosition{
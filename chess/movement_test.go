@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"sort"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestPawnMoves(t *testing.T) {
@@ -547,3 +549,136 @@ func isMoveEqual(a, b Move) bool {
 	}
 	return true
 }
+
+func TestCastleMoves(t *testing.T) {
+	table := []struct {
+		fen      string
+		expected []Square
+	}{
+		{
+			fen:      "4k3/8/8/8/8/8/8/R3K2R w KQ - 0 1",
+			expected: []Square{g1, c1},
+		},
+		{
+			// f1 is attacked, the king can't pass it
+			fen:      "4kr2/8/8/8/8/8/8/R3K2R w KQ - 0 1",
+			expected: []Square{c1},
+		},
+		{
+			// only the rook passes b1
+			fen:      "1r2k3/8/8/8/8/8/8/R3K2R w KQ - 0 1",
+			expected: []Square{g1, c1},
+		},
+		{
+			// a pawn on e2 attacks d1 and f1
+			fen:      "4k3/8/8/8/8/8/4p3/R3K2R w KQ - 0 1",
+			expected: nil,
+		},
+		{
+			fen:      "4k3/8/8/8/8/8/8/RN2K1NR w KQ - 0 1",
+			expected: nil,
+		},
+	}
+
+	for _, row := range table {
+		g := NewGameFromFEN(row.fen)
+		var got []Square
+		for _, move := range castleMoves(e1, g.Board.board, g.Context) {
+			got = append(got, move.ToSquare)
+		}
+		if !sameAfterSquareSort(got, row.expected) {
+			t.Errorf("got: %v, expected: %v for %s\n", printPrettySquares(got), printPrettySquares(row.expected), row.fen)
+		}
+	}
+}
+
+func TestMoveAccessors(t *testing.T) {
+	table := []struct {
+		fen               string
+		notation          string
+		expectedPiece     Piece
+		expectedCaptured  Piece
+		expectedPromotion Piece
+		expectedCapture   bool
+		expectedCastle    bool
+		expectedEnPassant bool
+		expectedCheck     bool
+		expectedUCI       string
+	}{
+		{
+			fen:           "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			notation:      "Nf3",
+			expectedPiece: WhiteKnight,
+			expectedUCI:   "g1f3",
+		},
+		{
+			fen:              "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2",
+			notation:         "exd5",
+			expectedPiece:    WhitePawn,
+			expectedCaptured: BlackPawn,
+			expectedCapture:  true,
+			expectedUCI:      "e4d5",
+		},
+		{
+			fen:               "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+			notation:          "exf6",
+			expectedPiece:     WhitePawn,
+			expectedCaptured:  BlackPawn,
+			expectedCapture:   true,
+			expectedEnPassant: true,
+			expectedUCI:       "e5f6",
+		},
+		{
+			fen:            "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1",
+			notation:       "O-O-O",
+			expectedPiece:  BlackKing,
+			expectedCastle: true,
+			expectedUCI:    "e8c8",
+		},
+		{
+			fen:               "2r1k3/3P4/8/8/8/8/8/4K3 w - - 0 1",
+			notation:          "dxc8=R+",
+			expectedPiece:     WhitePawn,
+			expectedCaptured:  BlackRook,
+			expectedPromotion: WhiteRook,
+			expectedCapture:   true,
+			expectedCheck:     true,
+			expectedUCI:       "d7c8r",
+		},
+		{
+			fen:           "4k3/8/8/8/8/8/8/R3K3 w Q - 0 1",
+			notation:      "Ra8+",
+			expectedPiece: WhiteRook,
+			expectedCheck: true,
+			expectedUCI:   "a1a8",
+		},
+	}
+
+	for _, row := range table {
+		g := NewGameFromFEN(row.fen)
+		g.Context.State = Playing
+		g.Players = []*Player{{Color: White}, {Color: Black}}
+		if err := g.PlayMove(row.notation); err != nil {
+			t.Fatalf("%s: %v", row.notation, err)
+		}
+		m := g.Moves[len(g.Moves)-1]
+		assert.Equal(t, row.expectedPiece, m.Piece(), row.notation)
+		assert.Equal(t, row.expectedCaptured, m.Captured(), row.notation)
+		assert.Equal(t, row.expectedPromotion, m.Promotion(), row.notation)
+		assert.Equal(t, row.expectedCapture, m.IsCapture(), row.notation)
+		assert.Equal(t, row.expectedCastle, m.IsCastle(), row.notation)
+		assert.Equal(t, row.expectedEnPassant, m.IsEnPassant(), row.notation)
+		assert.Equal(t, row.expectedCheck, m.IsCheck(), row.notation)
+		assert.Equal(t, row.expectedUCI, m.UCI(), row.notation)
+		assert.Equal(t, row.expectedCheck, inSlice(CheckMove, m.Flags()), row.notation)
+	}
+}
+
+func inSlice(mt MovementType, mts []MovementType) bool {
+	for _, each := range mts {
+		if each == mt {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrNotation      = errors.New("unrecognized move notation")
+	ErrAmbiguousMove = errors.New("ambiguous move")
+)
+
+var (
+	// Coordinate notation used by UCI engines: e2e4, e7e8q
+	uciRegexp = regexp.MustCompile(`^([a-h][1-8])([a-h][1-8])([qrbnQRBN])?$`)
+	// Long algebraic notation: Ng1-f3, e2-e4, Qd1xd7, e7-e8=Q
+	lanRegexp = regexp.MustCompile(`^([KQRBN])?([a-h][1-8])([-x:])([a-h][1-8])=?([QRBNqrbn])?$`)
+	// Standard algebraic notation: Nf3, exd5, Nbd2, R1a3, e8=Q
+	sanRegexp = regexp.MustCompile(`^([KQRBN])?([a-h])?([1-8])?[x:]?([a-h][1-8])=?([QRBN])?$`)
+)
+
+// moveFilter describes what is known of a move from its notation, the
+// zero value of a field matches anything
+type moveFilter struct {
+	piece     Piece // Kind of piece moving, Pawn, Knight, ...
+	from      Square
+	fromFile  byte
+	fromRank  byte
+	to        Square
+	promotion Piece // Kind of piece promoted to
+	castle    string
+}
+
+func (f moveFilter) matches(m Move, board [64]Piece) bool {
+	if f.castle != "" {
+		return m.IsCastle() && sanMove(m, board, Context{}) == f.castle
+	}
+	if f.piece != Empty && pieceKind(board[m.FromSquare]) != f.piece {
+		return false
+	}
+	if f.from != none && m.FromSquare != f.from {
+		return false
+	}
+	if f.fromFile != 0 && m.FromSquare.String()[0] != f.fromFile {
+		return false
+	}
+	if f.fromRank != 0 && m.FromSquare.String()[1] != f.fromRank {
+		return false
+	}
+	if m.ToSquare != f.to {
+		return false
+	}
+	// A pawn promotes to a queen if no piece is given
+	promotion := f.promotion
+	if promotion == Empty && m.promotion != Empty {
+		promotion = Queen
+	}
+	return pieceKind(m.promotion) == promotion
+}
+
+// parseMove finds the legal move in the position given by board and ctx
+// that the notation describes. Coordinate (e2e4), long algebraic (Ng1-f3)
+// and standard algebraic (Nf3, O-O) notation are accepted.
+func parseMove(notation string, board [64]Piece, ctx Context) (Move, error) {
+	s := strings.TrimSpace(notation)
+	s = strings.TrimRight(s, "+#!?")
+	s = strings.TrimSuffix(s, "e.p.")
+	s = strings.TrimSpace(s)
+
+	filter := moveFilter{from: none, to: none}
+	switch castle := strings.ReplaceAll(s, "0", "O"); {
+	case castle == "O-O" || castle == "OO":
+		filter.castle = "O-O"
+	case castle == "O-O-O" || castle == "OOO":
+		filter.castle = "O-O-O"
+	case uciRegexp.MatchString(s):
+		groups := uciRegexp.FindStringSubmatch(s)
+		filter.from = stringToSquare[groups[1]]
+		filter.to = stringToSquare[groups[2]]
+		filter.promotion = promotionFromString(groups[3])
+	case lanRegexp.MatchString(s):
+		groups := lanRegexp.FindStringSubmatch(s)
+		filter.piece = Pawn
+		if groups[1] != "" {
+			filter.piece = byteToPiece[groups[1][0]]
+		}
+		filter.from = stringToSquare[groups[2]]
+		filter.to = stringToSquare[groups[4]]
+		filter.promotion = promotionFromString(groups[5])
+	case sanRegexp.MatchString(s):
+		groups := sanRegexp.FindStringSubmatch(s)
+		filter.piece = Pawn
+		if groups[1] != "" {
+			filter.piece = byteToPiece[groups[1][0]]
+		}
+		if groups[2] != "" {
+			filter.fromFile = groups[2][0]
+		}
+		if groups[3] != "" {
+			filter.fromRank = groups[3][0]
+		}
+		filter.to = stringToSquare[groups[4]]
+		filter.promotion = promotionFromString(groups[5])
+	default:
+		return Move{}, fmt.Errorf("%q: %w", notation, ErrNotation)
+	}
+
+	var found []Move
+	for _, m := range validMovesForPlayer(ctx.ColorsTurn, board, ctx) {
+		if filter.matches(m, board) {
+			found = append(found, m)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Move{}, &NoMoveError{Move: notation}
+	case 1:
+		return found[0], nil
+	}
+	return Move{}, fmt.Errorf("%q: %w", notation, ErrAmbiguousMove)
+}
+
+var byteToPiece = map[byte]Piece{
+	'B': Bishop,
+	'N': Knight,
+	'R': Rook,
+	'Q': Queen,
+	'K': King,
+}
+
+func promotionFromString(s string) Piece {
+	if s == "" {
+		return Empty
+	}
+	return byteToPiece[strings.ToUpper(s)[0]]
+}
@@ -0,0 +1,171 @@
+package chess
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPlayMove(t *testing.T) {
+	const start = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	table := []struct {
+		fen      string
+		notation string
+		expected string
+		err      error
+	}{
+		{start, "e2e4", "e4", nil},
+		{start, "g1f3", "Nf3", nil},
+		{start, "e4", "e4", nil},
+		{start, "Nf3", "Nf3", nil},
+		{start, "Nf3!?", "Nf3", nil},
+		{start, "e2-e4", "e4", nil},
+		{start, "Ng1-f3", "Nf3", nil},
+		{start, "Bg1-f3", "", &NoMoveError{}},
+		{start, "e5", "", &NoMoveError{}},
+		{start, "Nd2", "", &NoMoveError{}},
+		{start, "e2e5", "", &NoMoveError{}},
+		{start, "hello", "", ErrNotation},
+		{start, "", "", ErrNotation},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "exd5", "exd5", nil},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "e4xd5", "exd5", nil},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "e4:d5", "exd5", nil},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "exf6", "exf6", nil},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "exf6e.p.", "exf6", nil},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "O-O", "O-O", nil},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "0-0", "O-O", nil},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1g1", "O-O", nil},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "O-O-O", "O-O-O", nil},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "0-0-0", "O-O-O", nil},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w - - 0 1", "O-O", "", &NoMoveError{}},
+		{"4k3/8/8/8/8/8/8/R4RK1 w - - 0 1", "Rd1", "", ErrAmbiguousMove},
+		{"4k3/8/8/8/8/8/8/R4RK1 w - - 0 1", "Rad1", "Rad1", nil},
+		{"4k3/8/8/8/8/8/8/R4RK1 w - - 0 1", "Rf1d1", "Rfd1", nil},
+		{"4k3/8/8/8/R7/8/8/R3K3 w Q - 0 1", "R1a3", "R1a3", nil},
+		{"3r3r/b2k4/3b4/R7/2K1Q2Q/8/8/R6Q w - - 0 1", "Qh4e1", "Qh4e1", nil},
+		{"3r3r/b2k4/3b4/R7/2K1Q2Q/8/8/R6Q w - - 0 1", "Qe1", "", ErrAmbiguousMove},
+		{"4k3/3P4/8/8/8/8/8/4K3 w - - 0 1", "d8=Q+", "d8=Q+", nil},
+		{"4k3/3P4/8/8/8/8/8/4K3 w - - 0 1", "d8N", "d8=N", nil},
+		{"4k3/3P4/8/8/8/8/8/4K3 w - - 0 1", "d8", "d8=Q+", nil},
+		{"4k3/3P4/8/8/8/8/8/4K3 w - - 0 1", "d7d8n", "d8=N", nil},
+		{"4k3/3P4/8/8/8/8/8/4K3 w - - 0 1", "d7-d8=R", "d8=R+", nil},
+		{"4k3/3P4/8/8/8/8/8/4K3 w - - 0 1", "d8=K", "", ErrNotation},
+		{"4k3/8/4K3/8/8/8/8/R7 w - - 0 1", "Ra8#", "Ra8#", nil},
+	}
+
+	for _, row := range table {
+		g := NewGameFromFEN(row.fen)
+		g.Context.State = Playing
+		g.Players = []*Player{{Color: White}, {Color: Black}}
+		var got string
+		if m, err := parseMove(row.notation, g.Board.board, g.Context); err == nil {
+			got = g.SAN(m)
+		}
+		err := g.PlayMove(row.notation)
+		var noMove *NoMoveError
+		switch {
+		case row.err == nil && err != nil:
+			t.Errorf("unexpected error %v for %s in %s\n", err, row.notation, row.fen)
+		case row.err == nil:
+			if got != row.expected {
+				t.Errorf("got: %s, expected: %s for %s in %s\n", got, row.expected, row.notation, row.fen)
+			}
+		case errors.As(row.err, &noMove):
+			if !errors.As(err, &noMove) {
+				t.Errorf("got: %v, expected NoMoveError for %s in %s\n", err, row.notation, row.fen)
+			}
+		case !errors.Is(err, row.err):
+			t.Errorf("got: %v, expected: %v for %s in %s\n", err, row.err, row.notation, row.fen)
+		}
+	}
+}
+
+func TestPlayMoveNotPlaying(t *testing.T) {
+	g := NewGame()
+	if err := g.PlayMove("e4"); err != ErrNotPlaying {
+		t.Errorf("got: %v, expected: %v\n", err, ErrNotPlaying)
+	}
+}
+
+func TestParseMove(t *testing.T) {
+	const (
+		start        = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+		afterE4      = "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"
+		crowded      = "3r3r/b2k4/3b4/R7/2K1Q2Q/8/8/R6Q w - - 0 1"
+		crowdedBlack = "3r3r/b2k4/3b4/R7/2K1Q2Q/8/8/R6Q b - - 0 1"
+		white        = "rnbqk3/8/8/8/8/8/8/RNBQK3 w - - 0 1"
+		black        = "rnbqk3/8/8/8/8/8/8/RNBQK3 b - - 0 1"
+		castle       = "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1"
+	)
+	regular := []MovementType{Regular}
+	pawnMove := []MovementType{Regular, PawnMove}
+	castling := []MovementType{Castle}
+	table := []struct {
+		fen       string
+		notation  string
+		piece     Piece
+		from, to  Square
+		promotion Piece
+		moveTypes []MovementType
+		err       error
+	}{
+		{start, "e4", WhitePawn, e2, e4, Empty, pawnMove, nil},
+		{start, "f3", WhitePawn, f2, f3, Empty, pawnMove, nil},
+		{afterE4, "Nf6", BlackKnight, g8, f6, Empty, regular, nil},
+		{"4k3/8/8/8/8/8/4N3/4K3 w - - 0 1", "Ng3", WhiteKnight, e2, g3, Empty, regular, nil},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "exd5", WhitePawn, e4, d5, Empty, []MovementType{Capture, PawnMove}, nil},
+
+		// Disambiguation by file, by rank, and by both
+		{crowdedBlack, "Bdb8", BlackBishop, d6, b8, Empty, regular, nil},
+		{crowdedBlack, "Rdf8", BlackRook, d8, f8, Empty, regular, nil},
+		{crowded, "R1a3", WhiteRook, a1, a3, Empty, regular, nil},
+		{crowded, "Qh4e1", WhiteQueen, h4, e1, Empty, regular, nil},
+		{"7k/8/8/8/8/8/7K/R6R w - - 0 1", "Rad1", WhiteRook, a1, d1, Empty, regular, nil},
+		{"7k/8/8/8/8/8/7K/R6R w - - 0 1", "Rhd1", WhiteRook, h1, d1, Empty, regular, nil},
+		{"7k/8/8/8/8/8/7K/R6R w - - 0 1", "Rd1", Empty, none, none, Empty, nil, ErrAmbiguousMove},
+		{"4k3/8/8/8/8/2N1N3/8/4K3 w - - 0 1", "Ncd5", WhiteKnight, c3, d5, Empty, regular, nil},
+		{"4k3/8/8/8/8/2N1N3/8/4K3 w - - 0 1", "Ned5", WhiteKnight, e3, d5, Empty, regular, nil},
+		{"R7/8/7k/8/8/8/8/R3K3 w - - 0 1", "R8a4", WhiteRook, a8, a4, Empty, regular, nil},
+		{"R7/8/7k/8/8/8/8/R3K3 w - - 0 1", "R1a4", WhiteRook, a1, a4, Empty, regular, nil},
+
+		// Promotion
+		{"4k3/P7/8/8/8/8/8/4K3 w - - 0 1", "a8=N", WhitePawn, a7, a8, WhiteKnight, []MovementType{Promotion, PawnMove}, nil},
+		{"4q1k1/3P4/8/8/8/8/8/K7 w - - 0 1", "dxe8=Q", WhitePawn, d7, e8, WhiteQueen, []MovementType{CapturePromotion, PawnMove}, nil},
+
+		// Castling
+		{castle, "O-O", WhiteKing, e1, g1, Empty, castling, nil},
+		{castle, "O-O-O", WhiteKing, e1, c1, Empty, castling, nil},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "O-O", BlackKing, e8, g8, Empty, castling, nil},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "O-O-O", BlackKing, e8, c8, Empty, castling, nil},
+
+		// The piece letter stands for the piece of the side to move
+		{white, "Ra2", WhiteRook, a1, a2, Empty, regular, nil},
+		{white, "Nc3", WhiteKnight, b1, c3, Empty, regular, nil},
+		{white, "Bd2", WhiteBishop, c1, d2, Empty, regular, nil},
+		{white, "Qd3", WhiteQueen, d1, d3, Empty, regular, nil},
+		{white, "Kf2", WhiteKing, e1, f2, Empty, regular, nil},
+		{black, "Ra7", BlackRook, a8, a7, Empty, regular, nil},
+		{black, "Nc6", BlackKnight, b8, c6, Empty, regular, nil},
+		{black, "Bd7", BlackBishop, c8, d7, Empty, regular, nil},
+		{black, "Qd6", BlackQueen, d8, d6, Empty, regular, nil},
+		{black, "Kf7", BlackKing, e8, f7, Empty, regular, nil},
+	}
+
+	for _, row := range table {
+		g := NewGameFromFEN(row.fen)
+		got, err := parseMove(row.notation, g.Board.board, g.Context)
+		if row.err != nil {
+			if !errors.Is(err, row.err) {
+				t.Errorf("got: %v, expected: %v for %s in %s\n", err, row.err, row.notation, row.fen)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error %v for %s in %s\n", err, row.notation, row.fen)
+			continue
+		}
+		expected := Move{piece: row.piece, FromSquare: row.from, ToSquare: row.to, moveTypes: row.moveTypes}
+		if !isMoveEqual(got, expected) || got.promotion != row.promotion {
+			t.Errorf("got: %s promoting to %s, expected: %s promoting to %s for %s in %s\n", got, got.promotion, expected, row.promotion, row.notation, row.fen)
+		}
+	}
+}
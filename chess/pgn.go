@@ -1,348 +1,250 @@
 package chess
 
 import (
+	"bufio"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"regexp"
 	"strings"
+	"time"
 )
 
-var fullMoveRegex = `\d+`
-var notationRegex = `[a-hBNRQKOx\-]+[+O1-8]+`
-var overRegex = `(1-0)|(0-1)|(1/2-1/2)`
-var allmovesRegexp = regexp.MustCompile(fmt.Sprintf(`(%s)\.(%s)\s+(%s)?\s*(%s)?\s*`,
-	fullMoveRegex,
-	notationRegex,
-	notationRegex,
-	overRegex))
-var gameOverRegexp = regexp.MustCompile(fmt.Sprintf(`(%s)\.(%s)\s+(%s)?\s*(%s)\s*`,
-	fullMoveRegex,
-	notationRegex,
-	notationRegex,
-	overRegex))
-var moveRegexp = regexp.MustCompile(fmt.Sprintf(`(%s)\.(%s)\s+(%s)\s*`,
-	fullMoveRegex,
-	notationRegex,
-	notationRegex))
+// PGN export lines are kept shorter than 80 characters
+const pgnLineLength = 79
 
-func pgnParse(reader io.Reader) ([]*Move, error) {
-	var moves []*Move
-	_ = moves
-	var pgnBytes []byte
-	var err error
+var tagPairRegexp = regexp.MustCompile(`^\[\s*([A-Za-z0-9_]+)\s+"((?:[^"\\]|\\.)*)"\s*\]$`)
 
-	pgnBytes, err = ioutil.ReadAll(reader)
-	if err != nil {
-		return nil, err
-	}
-	pgnString := filterMoves(string(pgnBytes))
-	movesStr := allmovesRegexp.FindAllString(pgnString, -1)
-	moves = getMoves(movesStr)
-	return moves, nil
+// TagPair is a PGN tag, [Name "Value"]
+type TagPair struct {
+	Name  string
+	Value string
 }
 
-func getMoves(allMoves []string) []*Move {
-	g := NewGame()
-	b := g.Board
-
-	type move struct {
-		player   Color
-		notation string
-	}
-	var realMoves []*Move
-	var realMove Move
-	var moves []*move
-	var groups []string
+// PGNGame is a game as written in PGN, the tag pairs in the order they
+// appear followed by the movetext
+type PGNGame struct {
+	Tags     []TagPair
+	Movetext string
+	Moves    []*MoveNode // Main line of the movetext
+	Result   string      // Game termination marker of the movetext
+	Index    int         // Index of the game in the stream it was read from
+	Offset   int64       // Byte offset of the game in the stream
+	Line     int         // Line number of the game in the stream, starting at 1
+}
 
-	for _, each := range allMoves {
-		if gameOverRegexp.MatchString(each) {
-			groups = gameOverRegexp.FindStringSubmatch(each)
-			if len(groups) == 5 {
-				moves = append(moves, &move{
-					player:   White,
-					notation: groups[2],
-				})
-				moves = append(moves, &move{
-					player:   Black,
-					notation: groups[3],
-				})
-			}
-			continue
+// Tag returns the value of the first tag with the given name, found is
+// false if the game has no such tag
+func (p *PGNGame) Tag(name string) (value string, found bool) {
+	for _, tag := range p.Tags {
+		if tag.Name == name {
+			return tag.Value, true
 		}
-		groups = moveRegexp.FindStringSubmatch(each)
-		moves = append(moves, &move{
-			player:   White,
-			notation: groups[2],
-		})
-		moves = append(moves, &move{
-			player:   Black,
-			notation: groups[3],
-		})
-	}
-	for _, m := range moves {
-		realMove = parseNotation(m.player, m.notation, b.board, g.Context)
-		realMoves = append(realMoves, &realMove)
-		b.board = makeMove(realMove, b.board)
 	}
-	return realMoves
+	return "", false
 }
 
-func parseNotation(player Color, playerMove string, board [64]Piece, context Context) Move {
-	var targetSquare Square
-
-	var fromInformation string
-	var promotion bool
-	var piece, promoPiece Piece
-	var movementTypes []MovementType
-	var move Move
+// ParsePGN reads the first game of a PGN stream, keeping its tag pairs and
+// movetext. io.EOF is returned if the stream holds no game.
+func ParsePGN(reader io.Reader) (*PGNGame, error) {
+	return NewPGNReader(reader).Next()
+}
 
-	if isCastle(playerMove) {
-		switch player {
-		case White:
-			piece = WhiteKing
-		case Black:
-			piece = BlackKing
-		}
-		fromSquare, toSquare := decodeCastleMust(player, playerMove)
-		movementTypes = append(movementTypes, Castle)
-		return createCastleMove(piece, fromSquare, toSquare, movementTypes)
+func parseTagPair(line string) (TagPair, error) {
+	groups := tagPairRegexp.FindStringSubmatch(line)
+	if groups == nil {
+		return TagPair{}, fmt.Errorf("malformed tag pair: %s", line)
 	}
+	return TagPair{Name: groups[1], Value: pgnUnescape(groups[2])}, nil
+}
 
-	if isCheck(playerMove) {
-		playerMove = playerMove[:len(playerMove)-1]
+// Game returns the game the PGN game describes. The moves of the main line
+// are played from the standard position, or the one given by the FEN tag,
+// with players named by the White and Black tags and the time control of
+// the TimeControl tag. Unless the moves or the result end it, the game is
+// left Idle to be played on after Start, which starts both clocks with the
+// full time of the time control.
+func (p *PGNGame) Game() (*Game, error) {
+	pgnError := func(err error) *PGNError {
+		return &PGNError{Game: p.Index, Offset: p.Offset, Line: p.Line, Err: err}
 	}
 
-	targetSquareString := playerMove[len(playerMove)-2:]
-	targetSquare = stringToSquare[targetSquareString]
-
-	if isPromotion(playerMove) {
-		promotion = true
-		bytePiece := playerMove[len(playerMove)-1]
-		promoPiece = byteToPiece[bytePiece]
-		playerMove = strings.Split(playerMove, "=")[0]
+	g := NewGame()
+	fen, hasFEN := p.Tag("FEN")
+	if setUp, _ := p.Tag("SetUp"); hasFEN && setUp != "0" {
+		var err error
+		g, err = ParseFEN(fen)
+		if err != nil {
+			return nil, pgnError(err)
+		}
 	}
-
-	isPawnMove := isPawn(playerMove)
-	if isPawnMove {
-		switch player {
-		case White:
-			piece = WhitePawn
-		case Black:
-			piece = BlackPawn
+	white, _ := p.Tag("White")
+	black, _ := p.Tag("Black")
+	g.Players = []*Player{{Color: White, ID: white}, {Color: Black, ID: black}}
+	if tag, found := p.Tag("TimeControl"); found {
+		if tc, err := ParseTimeControl(tag); err == nil {
+			g.timeControls = map[Color]TimeControl{White: tc, Black: tc}
 		}
-	} else {
-		bytePiece := playerMove[0]
-		piece = getPieceMust(byteToPiece[bytePiece], player)
 	}
-
-	if isCapture(playerMove) {
-		movementTypes = append(movementTypes, Capture)
-		fromInformation = strings.Split(playerMove, "x")[0]
-	} else {
-		fromInformation = playerMove[:len(playerMove)-2]
+	g.Context.State = Playing
+	if inCheck(getKingSquareMust(g.Context.ColorsTurn, g.Board.board), g.Board.board) {
+		g.Context.State = Check
 	}
 
-	if !isPawnMove {
-		movementTypes = append(movementTypes, Regular)
-		fromInformation = fromInformation[1:]
-	} else {
-		movementTypes = append(movementTypes, PawnMove)
-	}
-
-	file, rank := getFileRank(fromInformation)
-	fromSquares := findFromSquares(piece, targetSquare, board, context)
-	fromSquare := disambiguateMust(fromSquares, file, rank)
-
-	switch piece {
-	case WhitePawn, BlackPawn:
-		if promotion {
-			move = createPawnPromotionMove(board, fromSquare, targetSquare, promoPiece, []MovementType{Promotion})
-		} else {
-			move = createPawnMove(piece, fromSquare, targetSquare, movementTypes)
+	for ply, node := range p.Moves {
+		err := g.playMove(node.SAN)
+		if err != nil {
+			pgnErr := pgnError(err)
+			pgnErr.Ply, pgnErr.SAN, pgnErr.FEN = ply+1, node.SAN, g.FenString()
+			return nil, pgnErr
 		}
-	default:
-		move = createMove(board, fromSquare, targetSquare, movementTypes)
 	}
 
-	return move
-}
-
-func isCheck(move string) bool {
-	return move[len(move)-1] == '+'
-}
-
-func disambiguateMust(squares []Square, file byte, rank byte) Square {
-	if len(squares) == 1 {
-		return squares[0]
+	result := p.Result
+	if result == "" {
+		result, _ = p.Tag("Result")
 	}
-
-	//disambiguate by file first
-	if file != 0 && rank == 0 {
-		for _, square := range squares {
-			l, _ := getFileRank(square.String())
-			if file == l {
-				return square
-			}
-		}
-	}
-	//disambiguate by rank second
-	if file == 0 && rank != 0 {
-		for _, square := range squares {
-			_, r := getFileRank(square.String())
-			if rank == r {
-				return square
-			}
-		}
+	termination, _ := p.Tag("Termination")
+	g.endByResult(result, termination)
+	if g.Context.State == Playing || g.Context.State == Check {
+		g.Context.State = Idle
 	}
+	return g, nil
+}
 
-	if file != 0 && rank != 0 {
-		for _, square := range squares {
-			l, r := getFileRank(square.String())
-			if file == l && rank == r {
-				return square
-			}
-		}
+// endByResult ends a game still in progress with the result of its PGN,
+// the Termination tag tells a loss on time or by abandonment from a
+// resignation, and a draw is taken to be agreed
+func (g *Game) endByResult(result, termination string) {
+	if g.Context.State != Playing && g.Context.State != Check {
+		return
+	}
+	var winner Color
+	switch result {
+	case WhiteWon.String():
+		winner = White
+	case BlackWon.String():
+		winner = Black
+	case Drawn.String():
+		g.Context.State = Draw
+		g.Context.Termination = Agreement
+		return
+	default:
+		return
+	}
+	g.Context.State = Over
+	g.Context.WinningPlayer = g.getPlayer(winner)
+	switch termination {
+	case "time forfeit":
+		g.Context.Termination = Timeout
+	case "abandoned":
+		g.Context.Termination = Abandonment
+	default:
+		g.Context.Termination = Resignation
 	}
-
-	panic(fmt.Sprintf("no unique square found from squares: %s file: %q, rank: %q\n", squares, file, rank))
 }
 
-//Used for disambiguation
-func getFileRank(fromInformation string) (byte, byte) {
-	if len(fromInformation) == 2 {
-		return fromInformation[0], fromInformation[1]
-	}
-	if len(fromInformation) == 1 {
-		switch fromInformation[0] {
-		case 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h':
-			return fromInformation[0], 0
-		case '1', '2', '3', '4', '5', '6', '7', '8':
-			return 0, fromInformation[0]
-		}
-	}
-	return 0, 0
+// WritePGN writes the game in Portable Game Notation: the Seven Tag Roster,
+// SetUp and FEN tags when the game did not start from the standard
+// position, and the moves in SAN followed by the game termination marker
+func (g *Game) WritePGN(w io.Writer) error {
+	return g.PGN().WritePGN(w)
 }
 
-func decodeCastleMust(player Color, move string) (Square, Square) {
-	if player == White && move == "O-O" {
-		return e1, g1
+// PGN returns the game as a PGNGame
+func (g *Game) PGN() *PGNGame {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	pgn := &PGNGame{Tags: g.pgnTags(), Result: g.Context.Result().String()}
+	for _, m := range g.Moves {
+		pgn.Moves = append(pgn.Moves, &MoveNode{SAN: m.san})
 	}
-	if player == White && move == "O-O-O" {
-		return e1, c1
-	}
-	if player == Black && move == "O-O" {
-		return e8, g8
-	}
-	if player == Black && move == "O-O-O" {
-		return e8, c8
-	}
-	panic(fmt.Sprintf("called decode Castle with player: %v, move: %v\n", player, move))
+	return pgn
 }
 
-func getPieceMust(piece Piece, player Color) Piece {
-	if piece == Bishop && player == White {
-		return WhiteBishop
-	}
-	if piece == Knight && player == White {
-		return WhiteKnight
+// WritePGN writes the tag pairs in order and the move tree, with its
+// comments, NAGs and variations, followed by the game termination marker
+func (p *PGNGame) WritePGN(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, tag := range p.Tags {
+		fmt.Fprintf(bw, "[%s \"%s\"]\n", tag.Name, pgnEscape(tag.Value))
 	}
-	if piece == Rook && player == White {
-		return WhiteRook
-	}
-	if piece == Queen && player == White {
-		return WhiteQueen
-	}
-	if piece == King && player == White {
-		return WhiteKing
-	}
-	if piece == Bishop && player == Black {
-		return BlackBishop
-	}
-	if piece == Knight && player == Black {
-		return BlackKnight
-	}
-	if piece == Rook && player == Black {
-		return BlackRook
+	bw.WriteString("\n")
+
+	turn, fullMove := White, 1
+	if fen, found := p.Tag("FEN"); found {
+		if start, err := ParseFEN(fen); err == nil {
+			turn, fullMove = start.Context.ColorsTurn, start.Context.fullMove
+		}
 	}
-	if piece == Queen && player == Black {
-		return BlackQueen
+	result := p.Result
+	if result == "" {
+		result, _ = p.Tag("Result")
 	}
-	if piece == King && player == Black {
-		return BlackKing
+	if result == "" {
+		result = NoResult.String()
 	}
-	panic("no valid piece player combo")
 
-}
-
-func isPromotion(playerMove string) bool {
-	for i := range playerMove {
-		if playerMove[i] == '=' {
-			return true
+	var line string
+	for _, token := range append(movetextTokens(p.Moves, turn, fullMove), result) {
+		if line != "" && len(line)+1+len(token) > pgnLineLength {
+			bw.WriteString(line + "\n")
+			line = ""
 		}
+		if line != "" {
+			line += " "
+		}
+		line += token
 	}
-	return false
-}
-
-func isCastle(playerMove string) bool {
-	switch playerMove[0] {
-	case 'O':
-		return true
-	}
-	return false
+	bw.WriteString(line + "\n")
+	return bw.Flush()
 }
 
-func isCapture(playerMove string) bool {
-	for i := 0; i < len(playerMove); i++ {
-		if playerMove[i] == 'x' {
-			return true
+func (g *Game) pgnTags() []TagPair {
+	date := "????.??.??"
+	if g.startedAt != 0 {
+		date = time.Unix(0, g.startedAt).UTC().Format("2006.01.02")
+	}
+	white, black := "?", "?"
+	for _, p := range g.Players {
+		switch {
+		case p.Color == White && p.ID != "":
+			white = p.ID
+		case p.Color == Black && p.ID != "":
+			black = p.ID
 		}
 	}
-	return false
-}
-
-func isPawn(playerMove string) bool {
-	switch playerMove[0] {
-	case 'B', 'N', 'R', 'Q', 'K', 'O':
-		return false
+	tags := []TagPair{
+		{"Event", "?"},
+		{"Site", "?"},
+		{"Date", date},
+		{"Round", "?"},
+		{"White", white},
+		{"Black", black},
+		{"Result", g.Context.Result().String()},
 	}
-	return true
+	if g.initialFEN != "" {
+		tags = append(tags, TagPair{"SetUp", "1"}, TagPair{"FEN", g.initialFEN})
+	}
+	// Time odds can't be written in the tag
+	whiteTC, blackTC := g.timeControl(White), g.timeControl(Black)
+	if len(whiteTC.Periods) > 0 && whiteTC.Periods[0].Time > 0 && whiteTC.String() == blackTC.String() {
+		tags = append(tags, TagPair{"TimeControl", whiteTC.String()})
+	}
+	return tags
 }
 
-func findFromSquares(piece Piece, target Square, board [64]Piece, ctx Context) []Square {
-	fromSquares := getPieceSquares(piece, board)
-	var moves []Move
-	var returnMoves []Square
-	for _, fromSquare := range fromSquares {
-		moves = validMovesForSquare(fromSquare, board, ctx)
-		for _, move := range moves {
-			if move.ToSquare == target {
-				returnMoves = append(returnMoves, move.FromSquare)
-			}
-		}
-	}
-	return returnMoves
+func pgnEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, `"`, `\"`)
 }
 
-func filterMoves(s string) string {
-	var keep []string
-	for _, line := range strings.Split(s, "\n") {
-		if strings.HasPrefix(line, "[") {
-			continue
+func pgnUnescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
 		}
-		line = strings.Trim(line, "\n")
-		if len(line) == 0 {
-			continue
-		}
-		keep = append(keep, line)
+		b.WriteByte(s[i])
 	}
-	return strings.Join(keep, "\n")
-}
-
-var byteToPiece = map[byte]Piece{
-	'B': Bishop,
-	'N': Knight,
-	'R': Rook,
-	'Q': Queen,
-	'K': King,
+	return b.String()
 }
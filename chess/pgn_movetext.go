@@ -0,0 +1,266 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrPGNComment   = errors.New("unterminated comment")
+	ErrPGNVariation = errors.New("unbalanced variation")
+	ErrPGNNAG       = errors.New("misplaced NAG")
+	ErrPGNToken     = errors.New("unexpected token")
+)
+
+// MoveNode is a move of the movetext of a PGN game, with the comments and
+// Numeric Annotation Glyphs attached to it and the variations played
+// instead of it
+type MoveNode struct {
+	SAN            string
+	NAGs           []int
+	CommentsBefore []string      // Comments preceding the move
+	Comments       []string      // Comments following the move
+	Variations     [][]*MoveNode // Alternative lines starting with this move
+}
+
+type pgnTokenType byte
+
+const (
+	tokenSAN pgnTokenType = iota
+	tokenMoveNumber
+	tokenComment
+	tokenNAG
+	tokenVariationStart
+	tokenVariationEnd
+	tokenResult
+)
+
+type pgnToken struct {
+	typ  pgnTokenType
+	text string
+}
+
+// Move suffix annotations and the NAGs they stand for
+var suffixToNAG = map[string]int{
+	"!":  1,
+	"?":  2,
+	"!!": 3,
+	"??": 4,
+	"!?": 5,
+	"?!": 6,
+}
+
+func isSymbolByte(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.IndexByte("_+#=:-/", c) >= 0
+}
+
+// tokenizeMovetext splits movetext into tokens, ";" and "%" comments run to
+// the end of the line
+func tokenizeMovetext(s string) ([]pgnToken, error) {
+	var tokens []pgnToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '.':
+			i++
+		case c == '%' && (i == 0 || s[i-1] == '\n'):
+			i = endOfLine(s, i)
+		case c == ';':
+			end := endOfLine(s, i)
+			tokens = append(tokens, pgnToken{tokenComment, strings.TrimSpace(s[i+1 : end])})
+			i = end
+		case c == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return nil, ErrPGNComment
+			}
+			// Line breaks within comments are not part of their text
+			text := strings.Join(strings.Fields(s[i+1:i+end]), " ")
+			tokens = append(tokens, pgnToken{tokenComment, text})
+			i += end + 1
+		case c == '(':
+			tokens = append(tokens, pgnToken{tokenVariationStart, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, pgnToken{tokenVariationEnd, ")"})
+			i++
+		case c == '*':
+			tokens = append(tokens, pgnToken{tokenResult, "*"})
+			i++
+		case c == '$':
+			j := i + 1
+			for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+				j++
+			}
+			if j == i+1 {
+				return nil, fmt.Errorf("%w: %q", ErrPGNToken, s[i:j])
+			}
+			tokens = append(tokens, pgnToken{tokenNAG, s[i+1 : j]})
+			i = j
+		case c == '!' || c == '?':
+			j := i
+			for j < len(s) && (s[j] == '!' || s[j] == '?') {
+				j++
+			}
+			nag, found := suffixToNAG[s[i:j]]
+			if !found {
+				return nil, fmt.Errorf("%w: %q", ErrPGNToken, s[i:j])
+			}
+			tokens = append(tokens, pgnToken{tokenNAG, strconv.Itoa(nag)})
+			i = j
+		case isSymbolByte(c):
+			j := i
+			for j < len(s) && isSymbolByte(s[j]) {
+				j++
+			}
+			tokens = append(tokens, symbolToken(s[i:j]))
+			i = j
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrPGNToken, c)
+		}
+	}
+	return tokens, nil
+}
+
+func endOfLine(s string, i int) int {
+	if end := strings.IndexByte(s[i:], '\n'); end >= 0 {
+		return i + end
+	}
+	return len(s)
+}
+
+func symbolToken(symbol string) pgnToken {
+	switch symbol {
+	case "1-0", "0-1", "1/2-1/2":
+		return pgnToken{tokenResult, symbol}
+	}
+	if _, err := strconv.Atoi(symbol); err == nil {
+		return pgnToken{tokenMoveNumber, symbol}
+	}
+	return pgnToken{tokenSAN, symbol}
+}
+
+// parseMovetext builds the move tree of the movetext, result is the game
+// termination marker, empty if there is none
+func parseMovetext(s string) (moves []*MoveNode, result string, err error) {
+	tokens, err := tokenizeMovetext(s)
+	if err != nil {
+		return nil, "", err
+	}
+
+	type line struct {
+		moves  []*MoveNode
+		before []string // Comments waiting for the next move
+	}
+	lines := []*line{{}}
+	for i, token := range tokens {
+		current := lines[len(lines)-1]
+		var last *MoveNode
+		if len(current.moves) > 0 {
+			last = current.moves[len(current.moves)-1]
+		}
+		if result != "" {
+			return nil, "", fmt.Errorf("%w: %q after result", ErrPGNToken, token.text)
+		}
+
+		switch token.typ {
+		case tokenMoveNumber:
+		case tokenSAN:
+			current.moves = append(current.moves, &MoveNode{SAN: token.text, CommentsBefore: current.before})
+			current.before = nil
+		case tokenComment:
+			if last == nil || current.before != nil || (i > 0 && tokens[i-1].typ == tokenVariationEnd) {
+				current.before = append(current.before, token.text)
+				continue
+			}
+			last.Comments = append(last.Comments, token.text)
+		case tokenNAG:
+			if last == nil || current.before != nil {
+				return nil, "", ErrPGNNAG
+			}
+			nag, _ := strconv.Atoi(token.text)
+			last.NAGs = append(last.NAGs, nag)
+		case tokenVariationStart:
+			if last == nil {
+				return nil, "", ErrPGNVariation
+			}
+			lines = append(lines, &line{})
+		case tokenVariationEnd:
+			if len(lines) == 1 || len(current.moves) == 0 {
+				return nil, "", ErrPGNVariation
+			}
+			lines = lines[:len(lines)-1]
+			parent := lines[len(lines)-1].moves
+			end := current.moves[len(current.moves)-1]
+			end.Comments = append(end.Comments, current.before...)
+			variation := parent[len(parent)-1]
+			variation.Variations = append(variation.Variations, current.moves)
+		case tokenResult:
+			if len(lines) > 1 {
+				return nil, "", ErrPGNVariation
+			}
+			result = token.text
+		}
+	}
+	if len(lines) > 1 {
+		return nil, "", ErrPGNVariation
+	}
+	main := lines[0]
+	if len(main.moves) > 0 {
+		last := main.moves[len(main.moves)-1]
+		last.Comments = append(last.Comments, main.before...)
+	}
+	return main.moves, result, nil
+}
+
+// movetextTokens returns the tokens to write for the line of moves, the
+// first of which is played by turn in move fullMove
+func movetextTokens(moves []*MoveNode, turn Color, fullMove int) []string {
+	var tokens []string
+	comment := func(c string) {
+		tokens = append(tokens, strings.Fields("{"+c+"}")...)
+	}
+	needNumber := true
+	for _, m := range moves {
+		for _, c := range m.CommentsBefore {
+			comment(c)
+			needNumber = true
+		}
+		switch {
+		case turn == White:
+			tokens = append(tokens, fmt.Sprintf("%d.", fullMove))
+		case needNumber:
+			tokens = append(tokens, fmt.Sprintf("%d...", fullMove))
+		}
+		needNumber = false
+		tokens = append(tokens, m.SAN)
+		for _, nag := range m.NAGs {
+			tokens = append(tokens, fmt.Sprintf("$%d", nag))
+		}
+		for _, c := range m.Comments {
+			comment(c)
+			needNumber = true
+		}
+		for _, variation := range m.Variations {
+			if len(variation) == 0 {
+				continue
+			}
+			sub := movetextTokens(variation, turn, fullMove)
+			sub[0] = "(" + sub[0]
+			sub[len(sub)-1] += ")"
+			tokens = append(tokens, sub...)
+			needNumber = true
+		}
+		if turn == Black {
+			fullMove++
+		}
+		turn = turn.opponent()
+	}
+	return tokens
+}
@@ -0,0 +1,145 @@
+package chess
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMovetext(t *testing.T) {
+	table := []struct {
+		movetext       string
+		expectedMoves  []*MoveNode
+		expectedResult string
+		expectedError  error
+	}{
+		{
+			movetext: "1.e4 e5 2.Nf3 1-0",
+			expectedMoves: []*MoveNode{
+				{SAN: "e4"}, {SAN: "e5"}, {SAN: "Nf3"},
+			},
+			expectedResult: "1-0",
+		},
+		{
+			movetext: "{Opening} 1. e4 $1 {best by test} e5?! ; line comment\n2. Nf3!! *",
+			expectedMoves: []*MoveNode{
+				{SAN: "e4", NAGs: []int{1}, CommentsBefore: []string{"Opening"}, Comments: []string{"best by test"}},
+				{SAN: "e5", NAGs: []int{6}, Comments: []string{"line comment"}},
+				{SAN: "Nf3", NAGs: []int{3}},
+			},
+			expectedResult: "*",
+		},
+		{
+			movetext: "1.e4 e5 (1...c5 2.Nf3 (2.Nc3 Nc6) d6) (1...e6) 2.Nf3 1/2-1/2",
+			expectedMoves: []*MoveNode{
+				{SAN: "e4"},
+				{SAN: "e5", Variations: [][]*MoveNode{
+					{
+						{SAN: "c5"},
+						{SAN: "Nf3", Variations: [][]*MoveNode{{{SAN: "Nc3"}, {SAN: "Nc6"}}}},
+						{SAN: "d6"},
+					},
+					{{SAN: "e6"}},
+				}},
+				{SAN: "Nf3"},
+			},
+			expectedResult: "1/2-1/2",
+		},
+		{
+			movetext: "1.e4 e5 (1...c5 {Sicilian}) {back to the game} 2.Nf3",
+			expectedMoves: []*MoveNode{
+				{SAN: "e4"},
+				{SAN: "e5", Variations: [][]*MoveNode{{{SAN: "c5", Comments: []string{"Sicilian"}}}}},
+				{SAN: "Nf3", CommentsBefore: []string{"back to the game"}},
+			},
+		},
+		{
+			movetext: "%escaped\n1.O-O 0-0 2.exd8=Q+ Kxd8#",
+			expectedMoves: []*MoveNode{
+				{SAN: "O-O"}, {SAN: "0-0"}, {SAN: "exd8=Q+"}, {SAN: "Kxd8#"},
+			},
+		},
+		{movetext: "1.e4 {unterminated", expectedError: ErrPGNComment},
+		{movetext: "1.e4 (1.d4", expectedError: ErrPGNVariation},
+		{movetext: "1.e4 e5)", expectedError: ErrPGNVariation},
+		{movetext: "(1.e4) e5", expectedError: ErrPGNVariation},
+		{movetext: "$1 1.e4", expectedError: ErrPGNNAG},
+		{movetext: "1.e4 1-0 e5", expectedError: ErrPGNToken},
+		{movetext: "1.e4 ?!? e5", expectedError: ErrPGNToken},
+		{movetext: "1.e4 & e5", expectedError: ErrPGNToken},
+	}
+
+	for _, row := range table {
+		moves, result, err := parseMovetext(row.movetext)
+		if row.expectedError != nil {
+			if !errors.Is(err, row.expectedError) {
+				t.Errorf("got: %v, expected: %v for %q\n", err, row.expectedError, row.movetext)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error %v for %q\n", err, row.movetext)
+			continue
+		}
+		assert.Equal(t, row.expectedMoves, moves, row.movetext)
+		assert.Equal(t, row.expectedResult, result, row.movetext)
+	}
+}
+
+func TestWritePGNGame(t *testing.T) {
+	table := []struct {
+		pgnGame  string
+		expected string
+	}{
+		{
+			pgnGame: `[Event "Annotated"]
+[Result "1-0"]
+
+{Opening} 1.e4 $1 {best by test} e5?! (1...c5 2.Nf3 (2.Nc3 Nc6) d6) (1...e6)
+2.Nf3 Nc6 {a very long comment that has to be wrapped over several lines when it is written out again} 3.Bb5 1-0`,
+			expected: `[Event "Annotated"]
+[Result "1-0"]
+
+{Opening} 1. e4 $1 {best by test} 1... e5 $6 (1... c5 2. Nf3 (2. Nc3 Nc6) 2...
+d6) (1... e6) 2. Nf3 Nc6 {a very long comment that has to be wrapped over
+several lines when it is written out again} 3. Bb5 1-0
+`,
+		},
+		{
+			pgnGame: `[Event "Set up"]
+[SetUp "1"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 b - - 0 12"]
+
+12... Kd7 (12... Ke7 13. e4) 13. e4 *`,
+			expected: `[Event "Set up"]
+[SetUp "1"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 b - - 0 12"]
+
+12... Kd7 (12... Ke7 13. e4) 13. e4 *
+`,
+		},
+	}
+
+	for _, row := range table {
+		pgn, err := ParsePGN(strings.NewReader(row.pgnGame))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		if err := pgn.WritePGN(&b); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, row.expected, b.String())
+
+		// Written games read back the same
+		again, err := ParsePGN(strings.NewReader(b.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, pgn.Tags, again.Tags)
+		assert.Equal(t, pgn.Moves, again.Moves)
+		assert.Equal(t, pgn.Result, again.Result)
+	}
+}
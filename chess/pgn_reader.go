@@ -0,0 +1,157 @@
+package chess
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PGNError is an error in a game of a PGN stream, Game is the index of the
+// game, starting at 0, which starts at byte Offset on Line. Errors in the
+// moves also give the ply of the move, starting at 1, its SAN and the FEN
+// of the position before it.
+type PGNError struct {
+	Game   int
+	Offset int64
+	Line   int
+	Ply    int
+	SAN    string
+	FEN    string
+	Err    error
+}
+
+func (e *PGNError) Error() string {
+	if e.Ply == 0 {
+		return fmt.Sprintf("pgn game %d at line %d: %v", e.Game, e.Line, e.Err)
+	}
+	return fmt.Sprintf("pgn game %d at line %d: ply %d %s in %s: %v", e.Game, e.Line, e.Ply, e.SAN, e.FEN, e.Err)
+}
+
+func (e *PGNError) Unwrap() error {
+	return e.Err
+}
+
+// PGNReader reads the games of a PGN stream one at a time, so only a single
+// game is held in memory
+type PGNReader struct {
+	r       *bufio.Reader
+	offset  int64 // Offset of the next line
+	line    int   // Number of the next line
+	pending *pgnLine
+	games   int
+}
+
+type pgnLine struct {
+	text   string
+	offset int64
+	number int
+}
+
+func NewPGNReader(r io.Reader) *PGNReader {
+	return &PGNReader{r: bufio.NewReader(r), line: 1}
+}
+
+// Next returns the next game of the stream, io.EOF when there are no more
+// games. A game that can't be parsed is returned as a *PGNError, and the
+// following call continues with the next game.
+func (r *PGNReader) Next() (*PGNGame, error) {
+	pgn := &PGNGame{}
+	var movetext []string
+	var inComment, started, tagsEnded bool
+	var err error
+	for {
+		l, readErr := r.readLine()
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return nil, readErr
+		}
+		text := strings.TrimSpace(l.text)
+		if text == "" {
+			// The tag section ends at a blank line, a game without
+			// movetext ends there too
+			tagsEnded = started && len(movetext) == 0
+			continue
+		}
+		if strings.HasPrefix(l.text, "%") {
+			continue
+		}
+		isTag := !inComment && strings.HasPrefix(text, "[")
+		// A tag after the movetext, or after the blank line ending the tag
+		// section, starts the next game
+		if isTag && (len(movetext) > 0 || tagsEnded) {
+			r.pending = l
+			break
+		}
+		if !started {
+			started = true
+			pgn.Offset = l.offset
+			pgn.Line = l.number
+		}
+		if isTag {
+			tag, tagErr := parseTagPair(text)
+			if tagErr != nil && err == nil {
+				err = tagErr
+			}
+			pgn.Tags = append(pgn.Tags, tag)
+			continue
+		}
+		inComment = inBraceComment(text, inComment)
+		movetext = append(movetext, l.text)
+	}
+	if !started {
+		return nil, io.EOF
+	}
+	pgn.Movetext = strings.Join(movetext, "\n")
+	if err == nil {
+		pgn.Moves, pgn.Result, err = parseMovetext(pgn.Movetext)
+	}
+
+	pgn.Index = r.games
+	r.games++
+	if err != nil {
+		return nil, &PGNError{Game: pgn.Index, Offset: pgn.Offset, Line: pgn.Line, Err: err}
+	}
+	return pgn, nil
+}
+
+func (r *PGNReader) readLine() (*pgnLine, error) {
+	if r.pending != nil {
+		l := r.pending
+		r.pending = nil
+		return l, nil
+	}
+	text, err := r.r.ReadString('\n')
+	if err == io.EOF && text == "" {
+		return nil, io.EOF
+	}
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	l := &pgnLine{
+		text:   strings.TrimRight(text, "\r\n"),
+		offset: r.offset,
+		number: r.line,
+	}
+	r.offset += int64(len(text))
+	r.line++
+	return l, nil
+}
+
+// inBraceComment reports whether a {comment} is still open at the end of
+// the line, given whether one was open at its start
+func inBraceComment(line string, open bool) bool {
+	for i := 0; i < len(line); i++ {
+		switch {
+		case open && line[i] == '}':
+			open = false
+		case !open && line[i] == '{':
+			open = true
+		case !open && line[i] == ';':
+			return false
+		}
+	}
+	return open
+}
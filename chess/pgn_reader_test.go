@@ -0,0 +1,95 @@
+package chess
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPGNReader(t *testing.T) {
+	stream := "[Event \"one\"]\n" + // line 1, offset 0
+		"[Result \"1-0\"]\n" +
+		"\n" +
+		"1.e4 e5 2.Qh5 Nc6\r\n" +
+		"3.Bc4 Nf6 4.Qxf7 1-0\n" +
+		"\n" +
+		"[Event \"two\"\n" + // line 7, offset 71
+		"\n" +
+		"1.d4 d5 *\n" +
+		"\n" +
+		"% escaped line\n" +
+		"[Event \"three\"]\n" + // line 12, offset 111
+		"\n" +
+		"1.c4 {a comment\n" +
+		"[spanning] lines} e5 *\n" +
+		"[Event \"four\"]\n" + // line 16, offset 167
+		"1.Nf3 *"
+
+	type game struct {
+		event    string
+		movetext string
+		offset   int64
+		line     int
+	}
+	table := []struct {
+		expected      game
+		expectedError bool
+	}{
+		{expected: game{"one", "1.e4 e5 2.Qh5 Nc6\n3.Bc4 Nf6 4.Qxf7 1-0", 0, 1}},
+		{expected: game{offset: 71, line: 7}, expectedError: true},
+		{expected: game{"three", "1.c4 {a comment\n[spanning] lines} e5 *", 111, 12}},
+		{expected: game{"four", "1.Nf3 *", 167, 16}},
+	}
+
+	r := NewPGNReader(strings.NewReader(stream))
+	for i, row := range table {
+		got, err := r.Next()
+		if row.expectedError {
+			var pgnErr *PGNError
+			if !errors.As(err, &pgnErr) {
+				t.Fatalf("game %d: got: %v, expected a *PGNError\n", i, err)
+			}
+			assert.Equal(t, i, pgnErr.Game)
+			assert.Equal(t, row.expected.offset, pgnErr.Offset)
+			assert.Equal(t, row.expected.line, pgnErr.Line)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("game %d: %v\n", i, err)
+		}
+		event, _ := got.Tag("Event")
+		assert.Equal(t, row.expected, game{event, got.Movetext, got.Offset, got.Line})
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("got: %v, expected: %v\n", err, io.EOF)
+	}
+}
+
+func TestPGNReaderEmpty(t *testing.T) {
+	for _, stream := range []string{"", "\n\n", "% only an escape\n"} {
+		if _, err := NewPGNReader(strings.NewReader(stream)).Next(); err != io.EOF {
+			t.Errorf("got: %v, expected: %v for %q\n", err, io.EOF, stream)
+		}
+	}
+}
+
+func TestPGNReaderNoMovetext(t *testing.T) {
+	r := NewPGNReader(strings.NewReader("[Event \"a\"]\n\n[Event \"b\"]\n\n1. e4 *\n"))
+	var events []string
+	for {
+		pgn, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		event, _ := pgn.Tag("Event")
+		events = append(events, event)
+		assert.Len(t, pgn.Tags, 1)
+	}
+	assert.Equal(t, []string{"a", "b"}, events)
+}
@@ -1,7 +1,12 @@
 package chess
 
 import (
+	"errors"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestPGN(t *testing.T) {
@@ -65,7 +70,7 @@ func TestPGN(t *testing.T) {
 	}
 	for _, row := range table {
 		for _, move := range row.expectedMoves {
-			err := game.move(move.FromSquare, move.ToSquare)
+			err := game.move(move.FromSquare, move.ToSquare, Empty)
 			if err != nil {
 				t.Error(err)
 			}
@@ -77,7 +82,7 @@ func TestPGN(t *testing.T) {
 	}
 }
 
-func TestFilterComments(t *testing.T) {
+func TestPGNMovetext(t *testing.T) {
 	table := []struct {
 		pgnGame        string
 		expectedString string
@@ -93,7 +98,6 @@ func TestFilterComments(t *testing.T) {
 [WhiteElo ""]
 [BlackElo ""]
 [ECO "C05"]
-[]
 
 1.e4 e6 2.d4 d5 3.Nd2 Nf6 4.e5 Nfd7 5.f4 c5 6.c3 Nc6 7.Ndf3 cxd4 8.cxd4 f6
 9.Bd3 Bb4+ 10.Bd2 Qb6 11.Ne2 fxe5 12.fxe5 O-O 13.a3 Be7 14.Qc2 Rxf3 15.gxf3 Nxd4
@@ -109,423 +113,523 @@ func TestFilterComments(t *testing.T) {
 	}
 
 	for _, row := range table {
-		got := filterMoves(row.pgnGame)
-		if got != row.expectedString {
-			t.Errorf("got: %v, expected: %v\n", got, row.expectedString)
+		got, err := ParsePGN(strings.NewReader(row.pgnGame))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Movetext != row.expectedString {
+			t.Errorf("got: %v, expected: %v\n", got.Movetext, row.expectedString)
 		}
 	}
 }
 
-func TestFindFromSquare(t *testing.T) {
-	startFen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+func TestWritePGN(t *testing.T) {
 	table := []struct {
-		piece               Piece
-		toSquare            Square
-		game                *Game
-		expectedFromSquares []Square
+		fen          string
+		moves        []string
+		startingTime time.Duration
+		startedAt    int64
+		expected     string
 	}{
 		{
-			WhitePawn,
-			e4,
-			NewGameFromFEN(startFen),
-			[]Square{e2},
-		},
-		{
-			WhiteRook,
-			d1,
-			NewGameFromFEN("8/8/8/8/8/8/7K/R6R w - - 0 1"),
-			[]Square{a1, h1},
-		},
-	}
+			moves:        []string{"e4", "e5", "Qh5", "Nc6", "Bc4", "Nf6", "Qxf7#"},
+			startingTime: 5 * time.Minute,
+			startedAt:    time.Date(2020, 4, 2, 10, 0, 0, 0, time.UTC).UnixNano(),
+			expected: `[Event "?"]
+[Site "?"]
+[Date "2020.04.02"]
+[Round "?"]
+[White "alice"]
+[Black "bob"]
+[Result "1-0"]
+[TimeControl "300"]
 
-	for _, row := range table {
-		got := findFromSquares(row.piece, row.toSquare, row.game.Board.board, row.game.Context)
-		if !areSquaresEqual(got, row.expectedFromSquares) {
-			t.Errorf("got: %v, expected: %v\n", got, row.expectedFromSquares)
-		}
-	}
-}
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0
+`,
+		},
+		{
+			fen:   "4k3/3P4/8/8/8/8/8/4K2R b K - 0 40",
+			moves: []string{"Kxd7", "O-O"},
+			expected: `[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "alice"]
+[Black "bob"]
+[Result "*"]
+[SetUp "1"]
+[FEN "4k3/3P4/8/8/8/8/8/4K2R b K - 0 40"]
 
-func TestIsCapture(t *testing.T) {
-	table := []struct {
-		playerMove string
-		expected   bool
-	}{
-		{
-			"exd5",
-			true,
+40... Kxd7 41. O-O *
+`,
 		},
 		{
-			"Ng3",
-			false,
+			moves: []string{
+				"Nf3", "Nf6", "Ng1", "Ng8", "Nc3", "Nc6", "Nb1", "Nb8",
+				"Nf3", "Nf6", "Ng1", "Ng8", "Nc3", "Nc6", "Nb1", "Nb8",
+			},
+			expected: `[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "alice"]
+[Black "bob"]
+[Result "1/2-1/2"]
+
+1. Nf3 Nf6 2. Ng1 Ng8 3. Nc3 Nc6 4. Nb1 Nb8 5. Nf3 Nf6 6. Ng1 Ng8 7. Nc3 Nc6 8.
+Nb1 Nb8 1/2-1/2
+`,
 		},
 	}
 
 	for _, row := range table {
-		got := isCapture(row.playerMove)
-		if got != row.expected {
-			t.Errorf("got: %v, expected: %v\n", got, row.expected)
+		g := NewGame()
+		if row.fen != "" {
+			g = NewGameFromFEN(row.fen)
+		}
+		g.Context.State = Playing
+		g.Players = []*Player{{Color: White, ID: "alice"}, {Color: Black, ID: "bob"}}
+		g.StartingTime = row.startingTime
+		g.startedAt = row.startedAt
+		for _, m := range row.moves {
+			if err := g.PlayMove(m); err != nil {
+				t.Fatalf("move %s: %v", m, err)
+			}
+		}
+		var b strings.Builder
+		if err := g.WritePGN(&b); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != row.expected {
+			t.Errorf("got:\n%s\nexpected:\n%s", b.String(), row.expected)
+		}
+		for _, line := range strings.Split(b.String(), "\n") {
+			if len(line) > pgnLineLength {
+				t.Errorf("line longer than %d: %s", pgnLineLength, line)
+			}
 		}
 	}
 }
 
-func TestIsPawn(t *testing.T) {
+func TestParsePGN(t *testing.T) {
 	table := []struct {
-		playerMove string
-		expected   bool
+		pgnGame          string
+		expectedTags     []TagPair
+		expectedMovetext string
+		expectedError    bool
 	}{
 		{
-			"exd5",
-			true,
+			pgnGame: `[Event "Lloyds Bank op"]
+[Site "London"]
+[Date "1984.??.??"]
+[White "Adams, Michael"]
+[Black "Sedgwick, David"]
+[Result "1-0"]
+[WhiteElo ""]
+[ECO "C05"]
+[TimeControl "40/7200:3600"]
+
+1.e4 e6 2.d4 d5 1-0`,
+			expectedTags: []TagPair{
+				{"Event", "Lloyds Bank op"},
+				{"Site", "London"},
+				{"Date", "1984.??.??"},
+				{"White", "Adams, Michael"},
+				{"Black", "Sedgwick, David"},
+				{"Result", "1-0"},
+				{"WhiteElo", ""},
+				{"ECO", "C05"},
+				{"TimeControl", "40/7200:3600"},
+			},
+			expectedMovetext: "1.e4 e6 2.d4 d5 1-0",
 		},
 		{
-			"Ng3",
-			false,
+			pgnGame:          `[Event "The \"Immortal\" Game \\ London"]` + "\n\n1.e4 e5",
+			expectedTags:     []TagPair{{"Event", `The "Immortal" Game \ London`}},
+			expectedMovetext: "1.e4 e5",
 		},
 		{
-			"f3",
-			true,
+			pgnGame:          "1.e4 e5",
+			expectedMovetext: "1.e4 e5",
 		},
 		{
-			"O-O",
-			false,
+			pgnGame:       "[Event Lloyds]\n\n1.e4 e5",
+			expectedError: true,
 		},
 	}
 
 	for _, row := range table {
-		got := isPawn(row.playerMove)
-		if got != row.expected {
-			t.Errorf("got: %v, expected: %v\n", got, row.expected)
+		got, err := ParsePGN(strings.NewReader(row.pgnGame))
+		if row.expectedError {
+			if err == nil {
+				t.Errorf("expected error for %s\n", row.pgnGame)
+			}
+			continue
 		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, row.expectedTags, got.Tags)
+		assert.Equal(t, row.expectedMovetext, got.Movetext)
 	}
 }
 
-func TestIsCastle(t *testing.T) {
+func TestGameFromPGN(t *testing.T) {
 	table := []struct {
-		playerMove string
-		expected   bool
+		pgnGame             string
+		expectedFen         string
+		expectedMoves       [][2]Square
+		expectedState       State
+		expectedTermination Termination
+		expectedResult      Result
 	}{
 		{
-			"exd5",
-			false,
-		},
-		{
-			"Ng3",
-			false,
+			pgnGame:       "[Event \"?\"]\n\n1.e4 e5 2.Nf3 Nc6",
+			expectedFen:   "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3",
+			expectedMoves: [][2]Square{{e2, e4}, {e7, e5}, {g1, f3}, {b8, c6}},
+			expectedState: Playing,
 		},
 		{
-			"f3",
-			false,
-		},
-		{
-			"dxe8=Q",
-			false,
+			pgnGame: `[Event "?"]
+[SetUp "1"]
+[FEN "4k3/8/8/8/8/8/4P3/R3K3 w Q - 0 1"]
+
+1.O-O-O Kf7 2.e4 Kf6`,
+			expectedFen:   "8/8/5k2/8/4P3/8/8/2KR4 w - - 1 3",
+			expectedMoves: [][2]Square{{e1, c1}, {e8, f7}, {e2, e4}, {f7, f6}},
+			expectedState: Playing,
 		},
 		{
-			"O-O",
-			true,
-		},
-	}
-
-	for _, row := range table {
-		got := isCastle(row.playerMove)
-		if got != row.expected {
-			t.Errorf("got: %v, expected: %v\n", got, row.expected)
-		}
-	}
-}
+			pgnGame: `[Event "?"]
+[SetUp "0"]
+[FEN "4k3/8/8/8/8/8/4P3/R3K3 w Q - 0 1"]
 
-func TestIsPromotion(t *testing.T) {
-	table := []struct {
-		playerMove string
-		expected   bool
-	}{
+1.e4 e5`,
+			expectedFen:   "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2",
+			expectedMoves: [][2]Square{{e2, e4}, {e7, e5}},
+			expectedState: Playing,
+		},
 		{
-			"exd5",
-			false,
+			pgnGame:             "1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0",
+			expectedFen:         "r1bqkb1r/pppp1Qpp/2n2n2/4p3/2B1P3/8/PPPP1PPP/RNB1K1NR b KQkq - 0 4",
+			expectedMoves:       [][2]Square{{e2, e4}, {e7, e5}, {d1, h5}, {b8, c6}, {f1, c4}, {g8, f6}, {h5, f7}},
+			expectedState:       CheckMate,
+			expectedTermination: Checkmated,
+			expectedResult:      WhiteWon,
 		},
 		{
-			"Ng3",
-			false,
+			pgnGame:             "1. e4 e5 0-1",
+			expectedFen:         "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2",
+			expectedMoves:       [][2]Square{{e2, e4}, {e7, e5}},
+			expectedState:       Over,
+			expectedTermination: Resignation,
+			expectedResult:      BlackWon,
 		},
 		{
-			"a8=N",
-			true,
+			pgnGame:             "[Termination \"time forfeit\"]\n\n1. e4 1-0",
+			expectedFen:         "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1",
+			expectedMoves:       [][2]Square{{e2, e4}},
+			expectedState:       Over,
+			expectedTermination: Timeout,
+			expectedResult:      WhiteWon,
 		},
 		{
-			"f3",
-			false,
+			pgnGame:             "1. e4 e5 1/2-1/2",
+			expectedFen:         "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2",
+			expectedMoves:       [][2]Square{{e2, e4}, {e7, e5}},
+			expectedState:       Draw,
+			expectedTermination: Agreement,
+			expectedResult:      Drawn,
 		},
 		{
-			"dxe8=Q",
-			true,
+			pgnGame:       "[SetUp \"1\"]\n[FEN \"4k3/8/8/8/8/8/8/R3K3 w Q - 0 1\"]\n\n1. Ra8+ *",
+			expectedFen:   "R3k3/8/8/8/8/8/8/4K3 b - - 1 1",
+			expectedMoves: [][2]Square{{a1, a8}},
+			expectedState: Check,
 		},
 		{
-			"O-O",
-			false,
+			pgnGame:       "1. e4 d5 2. e5 f5 3. exf6 *",
+			expectedFen:   "rnbqkbnr/ppp1p1pp/5P2/3p4/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 3",
+			expectedMoves: [][2]Square{{e2, e4}, {d7, d5}, {e4, e5}, {f7, f5}, {e5, f6}},
+			expectedState: Playing,
 		},
 	}
 
 	for _, row := range table {
-		got := isPromotion(row.playerMove)
-		if got != row.expected {
-			t.Errorf("got: %v, expected: %v\n", got, row.expected)
+		g, err := GameFromPGN(strings.NewReader(row.pgnGame))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, row.expectedFen, g.FenString(), row.pgnGame)
+		var got [][2]Square
+		for _, m := range g.Moves {
+			got = append(got, [2]Square{m.FromSquare, m.ToSquare})
 		}
+		assert.Equal(t, row.expectedMoves, got, row.pgnGame)
+		// A game still in progress is played on once started
+		state := g.Context.State
+		if state == Idle {
+			cleanup := g.Start()
+			state = g.CurrentContext().State
+			cleanup()
+		}
+		assert.Equal(t, row.expectedState, state, row.pgnGame)
+		assert.Equal(t, row.expectedTermination, g.Context.Termination, row.pgnGame)
+		assert.Equal(t, row.expectedResult, g.Context.Result(), row.pgnGame)
 	}
 }
 
-func TestDecodeCastleMust(t *testing.T) {
-	table := []struct {
-		player     Color
-		playerMove string
-		expected   [2]Square
-	}{
-		{
-			White,
-			"O-O",
-			[2]Square{e1, g1},
-		},
-		{
-			White,
-			"O-O-O",
-			[2]Square{e1, c1},
-		},
-		{
-			Black,
-			"O-O",
-			[2]Square{e8, g8},
-		},
-		{
-			Black,
-			"O-O-O",
-			[2]Square{e8, c8},
-		},
+func TestGameFromPGNContinues(t *testing.T) {
+	pgnGame := `[White "alice"]
+[Black "bob"]
+
+1. e4 e5 2. Nf3 *`
+	g, err := GameFromPGN(strings.NewReader(pgnGame))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "alice", g.getPlayer(White).ID)
+	assert.Equal(t, "bob", g.getPlayer(Black).ID)
+	assert.Equal(t, Idle, g.Context.State)
+	assert.Equal(t, ErrNotPlaying, g.PlayMove("Nc6"))
+	cleanup := g.Start()
+	defer cleanup()
+	if err := g.PlayMove("Nc6"); err != nil {
+		t.Fatal(err)
 	}
+	var b strings.Builder
+	if err := g.WritePGN(&b); err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, b.String(), "1. e4 e5 2. Nf3 Nc6 *")
+}
 
-	for _, row := range table {
-		got1, got2 := decodeCastleMust(row.player, row.playerMove)
-		if [2]Square{got1, got2} != row.expected {
-			t.Errorf("got: %v, expected: %v\n", [2]Square{got1, got2}, row.expected)
-		}
+func TestGameFromPGNAfterEnd(t *testing.T) {
+	_, err := GameFromPGN(strings.NewReader("1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# Ke7 1-0"))
+	var pgnErr *PGNError
+	if !errors.As(err, &pgnErr) || !errors.Is(err, ErrNotPlaying) {
+		t.Fatalf("got: %v, expected a *PGNError for %v\n", err, ErrNotPlaying)
 	}
+	assert.Equal(t, 8, pgnErr.Ply)
 }
 
-func TestGetPieceMust(t *testing.T) {
+func TestPGNConformance(t *testing.T) {
 	table := []struct {
-		piece         Piece
-		player        Color
-		expectedPiece Piece
+		name           string
+		pgnGame        string
+		expectedMoves  []string
+		expectedResult string
 	}{
 		{
-			Bishop,
-			Black,
-			BlackBishop,
+			name:           "move numbers without space",
+			pgnGame:        "1.e4 e5 2.Nf3 Nc6 1/2-1/2",
+			expectedMoves:  []string{"e2e4", "e7e5", "g1f3", "b8c6"},
+			expectedResult: "1/2-1/2",
 		},
 		{
-			Knight,
-			Black,
-			BlackKnight,
+			name:           "move numbers with space",
+			pgnGame:        "1. e4 e5 2. Nf3 Nc6 1/2-1/2",
+			expectedMoves:  []string{"e2e4", "e7e5", "g1f3", "b8c6"},
+			expectedResult: "1/2-1/2",
 		},
 		{
-			Rook,
-			Black,
-			BlackRook,
+			name:          "ends on a white move without result",
+			pgnGame:       "1. e4 e5 2. Nf3",
+			expectedMoves: []string{"e2e4", "e7e5", "g1f3"},
 		},
 		{
-			Queen,
-			Black,
-			BlackQueen,
+			name:           "ends on a white move with result",
+			pgnGame:        "1. e4 e5 2. Nf3 0-1",
+			expectedMoves:  []string{"e2e4", "e7e5", "g1f3"},
+			expectedResult: "0-1",
 		},
 		{
-			King,
-			Black,
-			BlackKing,
+			name:           "unknown result",
+			pgnGame:        "1. d4 d5 *",
+			expectedMoves:  []string{"d2d4", "d7d5"},
+			expectedResult: "*",
 		},
 		{
-			Bishop,
-			White,
-			WhiteBishop,
+			name:           "checkmate marker",
+			pgnGame:        "1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0",
+			expectedMoves:  []string{"e2e4", "e7e5", "d1h5", "b8c6", "f1c4", "g8f6", "h5f7"},
+			expectedResult: "1-0",
 		},
 		{
-			Knight,
-			White,
-			WhiteKnight,
+			name:           "castling with zeros",
+			pgnGame:        "1. e4 e5 2. Nf3 Nc6 3. Bc4 Bc5 4. 0-0 Nf6 *",
+			expectedMoves:  []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1c4", "f8c5", "e1g1", "g8f6"},
+			expectedResult: "*",
 		},
 		{
-			Rook,
-			White,
-			WhiteRook,
+			name:          "black to move continuation",
+			pgnGame:       "[SetUp \"1\"]\n[FEN \"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1\"]\n\n1... e5 2. Nf3",
+			expectedMoves: []string{"e7e5", "g1f3"},
 		},
 		{
-			Queen,
-			White,
-			WhiteQueen,
+			name:          "black to move continuation without space",
+			pgnGame:       "[SetUp \"1\"]\n[FEN \"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1\"]\n\n1...e5 2.Nf3",
+			expectedMoves: []string{"e7e5", "g1f3"},
 		},
 		{
-			King,
-			White,
-			WhiteKing,
+			name:          "long castling with zeros and black moves numbered",
+			pgnGame:       "[SetUp \"1\"]\n[FEN \"r3k3/8/8/8/8/8/8/4K2R w Kq - 0 1\"]\n\n1. 0-0 1... 0-0-0",
+			expectedMoves: []string{"e1g1", "e8c8"},
 		},
-	}
-
-	for _, row := range table {
-		got := getPieceMust(row.piece, row.player)
-		if got != row.expectedPiece {
-			t.Errorf("got: %v, expected: %v\n", got, row.expectedPiece)
-		}
-	}
-}
-
-func TestGetFileRank(t *testing.T) {
-	table := []struct {
-		fromInformation string
-		expectedFile    byte
-		expectedRank    byte
-	}{
 		{
-			"e1",
-			'e',
-			'1',
+			name:           "promotion with check",
+			pgnGame:        "[SetUp \"1\"]\n[FEN \"4k3/P7/8/8/8/8/8/4K3 w - - 0 1\"]\n\n1. a8=Q+ Kd7 2. Qa4+ *",
+			expectedMoves:  []string{"a7a8q", "e8d7", "a8a4"},
+			expectedResult: "*",
 		},
 		{
-			"d",
-			'd',
-			0,
+			name:           "promotion without equals sign",
+			pgnGame:        "[SetUp \"1\"]\n[FEN \"4k3/8/8/8/8/8/3p4/2B4K b - - 0 1\"]\n\n1... dxc1Q *",
+			expectedMoves:  []string{"d2c1q"},
+			expectedResult: "*",
 		},
 		{
-			"8",
-			0,
-			'8',
+			name:           "annotations, comments and variations",
+			pgnGame:        "1. e4! {King's pawn} e5?? (1... c5 $1) 2. Qh5 $2 ; odd\nNc6!? 0-1",
+			expectedMoves:  []string{"e2e4", "e7e5", "d1h5", "b8c6"},
+			expectedResult: "0-1",
 		},
 		{
-			"",
-			0,
-			0,
+			name:          "movetext over several lines",
+			pgnGame:       "1. e4\ne5\n2.\nNf3",
+			expectedMoves: []string{"e2e4", "e7e5", "g1f3"},
 		},
 	}
 
 	for _, row := range table {
-		gotFile, gotRank := getFileRank(row.fromInformation)
-		if gotFile != row.expectedFile || gotRank != row.expectedRank {
-			t.Errorf("got: %v %v, expected: %v %v\n", gotFile, gotRank, row.expectedFile, row.expectedRank)
+		pgn, err := ParsePGN(strings.NewReader(row.pgnGame))
+		if err != nil {
+			t.Errorf("%s: %v\n", row.name, err)
+			continue
+		}
+		g, err := pgn.Game()
+		if err != nil {
+			t.Errorf("%s: %v\n", row.name, err)
+			continue
+		}
+		var got []string
+		for _, m := range g.Moves {
+			got = append(got, m.FromSquare.String()+m.ToSquare.String()+promotionToString[pieceKind(m.promotion)])
 		}
+		assert.Equal(t, row.expectedMoves, got, row.name)
+		assert.Equal(t, row.expectedResult, pgn.Result, row.name)
 	}
 }
 
-func TestDisambiguateMust(t *testing.T) {
+func TestPGNIllegalCastling(t *testing.T) {
 	table := []struct {
-		squares        []Square
-		file           byte
-		rank           byte
-		expectedSquare Square
+		name    string
+		pgnGame string
 	}{
 		{
-			squares:        []Square{e3, c3},
-			file:           'c',
-			rank:           0,
-			expectedSquare: c3,
+			name:    "through an attacked square",
+			pgnGame: "[SetUp \"1\"]\n[FEN \"r3k3/8/8/8/8/8/8/R3K3 w Qq - 0 1\"]\n\n1. 0-0-0 1... 0-0-0",
 		},
 		{
-			squares:        []Square{a1, h1},
-			file:           'h',
-			rank:           0,
-			expectedSquare: h1,
+			name:    "out of check",
+			pgnGame: "[SetUp \"1\"]\n[FEN \"r3k3/8/8/8/8/8/8/4RK2 b q - 0 1\"]\n\n1... O-O-O",
 		},
 		{
-			squares:        []Square{a1, a8},
-			file:           0,
-			rank:           '8',
-			expectedSquare: a8,
-		},
-		{
-			squares:        []Square{e4, h4, h1},
-			file:           'h',
-			rank:           '4',
-			expectedSquare: h4,
+			name:    "without the right",
+			pgnGame: "[SetUp \"1\"]\n[FEN \"r3k3/8/8/8/8/8/8/4K3 b - - 0 1\"]\n\n1... O-O-O",
 		},
 	}
-
 	for _, row := range table {
-		gotSquare := disambiguateMust(row.squares, row.file, row.rank)
-		if gotSquare != row.expectedSquare {
-			t.Errorf("got: %s, expected: %s\n", gotSquare, row.expectedSquare)
+		pgn, err := ParsePGN(strings.NewReader(row.pgnGame))
+		if err != nil {
+			t.Errorf("%s: %v\n", row.name, err)
+			continue
+		}
+		_, err = pgn.Game()
+		var noMove *NoMoveError
+		if !errors.As(err, &noMove) {
+			t.Errorf("%s: got: %v, expected a *NoMoveError\n", row.name, err)
 		}
 	}
 }
 
-func TestParseNotation(t *testing.T) {
-	fen0 := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
-	fen1 := "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 1"
-	fen3 := "3r3r/b2k4/3b4/R7/2K1Q2Q/8/8/R6Q w KQkq - 0 1"
+func TestPGNMoveErrors(t *testing.T) {
+	stream := `[Event "fine"]
+
+1. e4 e5 *
+
+[Event "illegal"]
+
+1. e4 e5 2. Ke3 *
 
+[Event "ambiguous"]
+[SetUp "1"]
+[FEN "4k3/8/8/8/8/8/8/R4RK1 w - - 0 1"]
+
+1. Rd1 *
+
+[Event "gibberish"]
+
+1. e4 Xe5 *
+
+[Event "bad castle"]
+
+1. O-O *
+`
 	table := []struct {
-		player       Color
-		notation     string
-		board        [64]Piece
-		context      Context
-		expectedMove Move
+		expectedPly   int
+		expectedSAN   string
+		expectedFEN   string
+		expectedError error
 	}{
-		{
-			player:       White,
-			notation:     "e4",
-			board:        NewGameFromFEN(fen0).Board.board,
-			context:      NewGameFromFEN(fen0).Context,
-			expectedMove: createPawnMove(WhitePawn, e2, e4, []MovementType{PawnMove}),
-		},
-		{
-			player:       Black,
-			notation:     "Nf6",
-			board:        NewGameFromFEN(fen1).Board.board,
-			context:      NewGameFromFEN(fen1).Context,
-			expectedMove: createMove(NewGameFromFEN(fen1).Board.board, g8, f6, []MovementType{Regular}),
-		},
-		{
-			player:       Black,
-			notation:     "Bdb8",
-			board:        NewGameFromFEN(fen3).Board.board,
-			context:      NewGameFromFEN(fen3).Context,
-			expectedMove: createMove(NewGameFromFEN(fen3).Board.board, d6, b8, []MovementType{Regular}),
-		},
-		{
-			player:       Black,
-			notation:     "Rdf8",
-			board:        NewGameFromFEN(fen3).Board.board,
-			context:      NewGameFromFEN(fen3).Context,
-			expectedMove: createMove(NewGameFromFEN(fen3).Board.board, d8, f8, []MovementType{Regular}),
-		},
-		{
-			player:       White,
-			notation:     "R1a3",
-			board:        NewGameFromFEN(fen3).Board.board,
-			context:      NewGameFromFEN(fen3).Context,
-			expectedMove: createMove(NewGameFromFEN(fen3).Board.board, a1, a3, []MovementType{Regular}),
-		},
-		{
-			player:       White,
-			notation:     "Qh4e1",
-			board:        NewGameFromFEN(fen3).Board.board,
-			context:      NewGameFromFEN(fen3).Context,
-			expectedMove: createMove(NewGameFromFEN(fen3).Board.board, h4, e1, []MovementType{Regular}),
-		},
+		{},
+		{3, "Ke3", "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", &NoMoveError{}},
+		{1, "Rd1", "4k3/8/8/8/8/8/8/R4RK1 w - - 0 1", ErrAmbiguousMove},
+		{2, "Xe5", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1", ErrNotation},
+		{1, "O-O", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", &NoMoveError{}},
 	}
 
-	for _, row := range table {
-		gotMove := parseNotation(row.player, row.notation, row.board, row.context)
-		if !isMoveEqual(gotMove, row.expectedMove) {
-			t.Errorf("got: %s, expected: %s\n", gotMove, row.expectedMove)
+	r := NewPGNReader(strings.NewReader(stream))
+	for i, row := range table {
+		pgn, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pgn.Game()
+		if row.expectedError == nil {
+			if err != nil {
+				t.Errorf("game %d: unexpected error %v\n", i, err)
+			}
+			continue
+		}
+		var pgnErr *PGNError
+		if !errors.As(err, &pgnErr) {
+			t.Errorf("game %d: got: %v, expected a *PGNError\n", i, err)
+			continue
+		}
+		assert.Equal(t, i, pgnErr.Game)
+		assert.Equal(t, row.expectedPly, pgnErr.Ply)
+		assert.Equal(t, row.expectedSAN, pgnErr.SAN)
+		assert.Equal(t, row.expectedFEN, pgnErr.FEN)
+		var noMove *NoMoveError
+		if errors.As(row.expectedError, &noMove) {
+			if !errors.As(err, &noMove) {
+				t.Errorf("game %d: got: %v, expected a *NoMoveError\n", i, err)
+			}
+			continue
+		}
+		if !errors.Is(err, row.expectedError) {
+			t.Errorf("game %d: got: %v, expected: %v\n", i, err, row.expectedError)
 		}
 	}
 }
 
-func areSquaresEqual(a, b []Square) bool {
-	if len(a) != len(b) {
-		return false
-
-	}
-	for i := range a {
-		if a[i] != b[i] {
-			return false
-		}
+func TestGameFromPGNError(t *testing.T) {
+	_, err := GameFromPGN(strings.NewReader("1. e4 e5 2. Qxf7 *"))
+	var pgnErr *PGNError
+	if !errors.As(err, &pgnErr) {
+		t.Fatalf("got: %v, expected a *PGNError\n", err)
 	}
-	return true
+	assert.Equal(t, 3, pgnErr.Ply)
+	assert.Equal(t, "Qxf7", pgnErr.SAN)
 }
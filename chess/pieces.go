@@ -15,3 +15,30 @@ var pieceToUnicode = map[Piece]string{
 	BlackQueen:  "\u265B",
 	BlackKing:   "\u265A",
 }
+
+var promotionToString = map[Piece]string{
+	Knight: "n",
+	Bishop: "b",
+	Rook:   "r",
+	Queen:  "q",
+}
+
+// pieceKind strips the color of a piece, WhiteQueen and BlackQueen are
+// both a Queen
+func pieceKind(p Piece) Piece {
+	switch p {
+	case WhitePawn, BlackPawn:
+		return Pawn
+	case WhiteKnight, BlackKnight:
+		return Knight
+	case WhiteBishop, BlackBishop:
+		return Bishop
+	case WhiteRook, BlackRook:
+		return Rook
+	case WhiteQueen, BlackQueen:
+		return Queen
+	case WhiteKing, BlackKing:
+		return King
+	}
+	return p
+}
@@ -1,5 +1,11 @@
 package chess
 
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
 //func TestPromotion(t *testing.T) {
 //	type piecePosition struct {
 //		position Square
@@ -52,3 +58,77 @@ package chess
 //		}
 //	}
 //}
+
+func TestPromotionChoice(t *testing.T) {
+	fen := "k7/4P3/8/8/8/8/8/4K3 w - - 0 1"
+	table := []struct {
+		move          string
+		expectedPiece Piece
+		expectedError bool
+	}{
+		{"e7e8", WhiteQueen, false},
+		{"e7e8q", WhiteQueen, false},
+		{"e7e8r", WhiteRook, false},
+		{"e7e8B", WhiteBishop, false},
+		{"e7e8n", WhiteKnight, false},
+		{"e7e8k", Empty, true},
+		{"e7e8x", Empty, true},
+		{"e1e2q", Empty, true},
+	}
+
+	for _, row := range table {
+		g := NewGameFromFEN(fen)
+		g.Context.State = Playing
+		g.Players = []*Player{
+			{
+				Color: White,
+			},
+			{
+				Color: Black,
+			},
+		}
+		err := g.Move(row.move)
+		if (err != nil) != row.expectedError {
+			t.Errorf("got error: %v, expected error: %v for %s\n", err, row.expectedError, row.move)
+			continue
+		}
+		if err == nil && g.Board.board[e8] != row.expectedPiece {
+			t.Errorf("got: %s, expected: %s for %s\n", g.Board.board[e8], row.expectedPiece, row.move)
+		}
+	}
+}
+
+func TestPromotionNoMove(t *testing.T) {
+	const fen = "k7/4P3/8/8/8/8/8/4K3 w - - 0 1"
+	for _, promo := range []Piece{King, Pawn, WhiteQueen} {
+		g := NewGameFromFEN(fen)
+		g.Context.State = Playing
+		g.Players = []*Player{{Color: White}, {Color: Black}}
+		if err := g.move(e7, e8, promo); err == nil {
+			t.Errorf("got no error, expected one for a promotion to %s\n", promo)
+		}
+		if g.FenString() != fen || len(g.Moves) != 0 {
+			t.Errorf("got: %s, expected the position to be unchanged for a promotion to %s\n", g.FenString(), promo)
+		}
+	}
+}
+
+func TestValidMovesPromotion(t *testing.T) {
+	g := NewGameFromFEN("k7/4P3/8/8/8/8/8/7K w - - 0 1")
+	g.Context.State = Playing
+	moves, err := ValidMoves(g.Board, White, g.Context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var promotions []string
+	for _, move := range moves {
+		if move[:2] == "e7" {
+			promotions = append(promotions, move)
+		}
+	}
+	sort.Strings(promotions)
+	expected := []string{"e7e8b", "e7e8n", "e7e8q", "e7e8r"}
+	if strings.Join(promotions, ",") != strings.Join(expected, ",") {
+		t.Errorf("got: %v, expected: %v\n", promotions, expected)
+	}
+}
@@ -0,0 +1,119 @@
+package chess
+
+var pieceToSAN = map[Piece]string{
+	Knight: "N",
+	Bishop: "B",
+	Rook:   "R",
+	Queen:  "Q",
+	King:   "K",
+}
+
+// SAN returns the move in Standard Algebraic Notation, "Nbd2", "exd6",
+// "e8=Q+", "O-O-O#", as played in the position given by b and c
+func (m Move) SAN(b *Board, c Context) string {
+	return san(m, b.board, c)
+}
+
+// SAN returns the move in Standard Algebraic Notation, as played in the
+// current position of the game
+func (g *Game) SAN(m Move) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return san(m, g.Board.board, g.Context)
+}
+
+func san(m Move, board [64]Piece, ctx Context) string {
+	return sanMove(m, board, ctx) + sanSuffix(m, board, ctx)
+}
+
+// sanMove returns the notation of the move without check or mate suffix
+func sanMove(m Move, board [64]Piece, ctx Context) string {
+	if m.IsCastle() {
+		if m.ToSquare.col() == g1.col() {
+			return "O-O"
+		}
+		return "O-O-O"
+	}
+
+	piece := board[m.FromSquare]
+	kind := pieceKind(piece)
+	isCapture := board[m.ToSquare] != Empty
+	for _, mt := range m.moveTypes {
+		if mt == CaptureEnPassant {
+			isCapture = true
+		}
+	}
+
+	var s string
+	if kind == Pawn {
+		if isCapture {
+			s += m.FromSquare.String()[:1] + "x"
+		}
+		s += m.ToSquare.String()
+		if m.promotion != Empty {
+			s += "=" + pieceToSAN[pieceKind(m.promotion)]
+		}
+		return s
+	}
+
+	s += pieceToSAN[kind] + sanDisambiguation(m, board, ctx)
+	if isCapture {
+		s += "x"
+	}
+	return s + m.ToSquare.String()
+}
+
+// sanDisambiguation returns the file, the rank, or both, of the square the
+// piece moves from, if another piece of the same kind can move to the
+// same square
+func sanDisambiguation(m Move, board [64]Piece, ctx Context) string {
+	piece := board[m.FromSquare]
+	var others []Square
+	for _, sq := range getPieceSquares(piece, board) {
+		if sq == m.FromSquare {
+			continue
+		}
+		for _, move := range validMovesForSquare(sq, board, ctx) {
+			if move.ToSquare == m.ToSquare {
+				others = append(others, sq)
+				break
+			}
+		}
+	}
+	if len(others) == 0 {
+		return ""
+	}
+
+	sameFile, sameRank := false, false
+	for _, sq := range others {
+		if sq.col() == m.FromSquare.col() {
+			sameFile = true
+		}
+		if sq.row() == m.FromSquare.row() {
+			sameRank = true
+		}
+	}
+	from := m.FromSquare.String()
+	switch {
+	case !sameFile:
+		return from[:1]
+	case !sameRank:
+		return from[1:]
+	}
+	return from
+}
+
+// sanSuffix returns "#" if the move mates, "+" if it checks
+func sanSuffix(m Move, board [64]Piece, ctx Context) string {
+	opponent := m.Color.opponent()
+	after := makeMove(m, board)
+	if !inCheck(getKingSquareMust(opponent, after), after) {
+		return ""
+	}
+	ctx.ColorsTurn = opponent
+	ctx.enPassantSquare = enPassantSquare(m, after)
+	if len(validMovesForPlayer(opponent, after, ctx)) == 0 {
+		return "#"
+	}
+	return "+"
+}
@@ -0,0 +1,60 @@
+package chess
+
+import (
+	"testing"
+)
+
+func TestSAN(t *testing.T) {
+	table := []struct {
+		fen      string
+		from     Square
+		to       Square
+		promo    Piece
+		expected string
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", e2, e4, Empty, "e4"},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", g1, f3, Empty, "Nf3"},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", e4, d5, Empty, "exd5"},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", e5, f6, Empty, "exf6"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", e1, g1, Empty, "O-O"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", e8, c8, Empty, "O-O-O"},
+		{"4k3/8/8/8/8/8/8/RN2K2R w K - 0 1", e1, g1, Empty, "O-O"},
+		{"4k3/8/8/8/8/8/8/R3K2R w KQ - 0 1", a1, d1, Empty, "Rd1"},
+		{"4k3/8/8/8/8/8/8/R4RK1 w - - 0 1", a1, d1, Empty, "Rad1"},
+		{"4k3/8/8/8/8/8/8/R4RK1 w - - 0 1", f1, d1, Empty, "Rfd1"},
+		{"4k3/8/8/8/8/8/8/R3K2R w KQ - 0 1", h1, h8, Empty, "Rh8+"},
+		{"4k3/8/8/8/R7/8/8/R3K3 w Q - 0 1", a1, a3, Empty, "R1a3"},
+		{"3r3r/b2k4/3b4/R7/2K1Q2Q/8/8/R6Q w - - 0 1", h4, e1, Empty, "Qh4e1"},
+		{"3r3r/b2k4/3b4/R7/2K1Q2Q/8/8/R6Q w - - 0 1", h1, e1, Empty, "Q1e1"},
+		{"3r3r/b2k4/3b4/R7/2K1Q2Q/8/8/R6Q w - - 0 1", e4, e1, Empty, "Qee1"},
+		{"k7/8/8/8/1N3N2/8/8/K7 w - - 0 1", b4, d5, Empty, "Nbd5"},
+		{"k7/8/8/8/1N3N2/8/8/K7 w - - 0 1", b4, a6, Empty, "Na6"},
+		{"4k3/8/8/8/8/8/8/3NKN2 w - - 0 1", d1, e3, Empty, "Nde3"},
+		{"4k3/3P4/8/8/8/8/8/4K3 w - - 0 1", d7, d8, WhiteQueen, "d8=Q+"},
+		{"4k3/3P4/8/8/8/8/8/4K3 w - - 0 1", d7, d8, WhiteKnight, "d8=N"},
+		{"2r1k3/3P4/8/8/8/8/8/4K3 w - - 0 1", d7, c8, WhiteRook, "dxc8=R+"},
+		{"4k3/8/4K3/8/8/8/8/R7 w - - 0 1", a1, a8, Empty, "Ra8#"},
+		{"rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2", d8, h4, Empty, "Qh4#"},
+		{"4k3/8/8/8/8/8/8/3rK3 w - - 0 1", e1, d1, Empty, "Kxd1"},
+	}
+
+	for _, row := range table {
+		g := NewGameFromFEN(row.fen)
+		var found bool
+		for _, move := range validMovesForSquare(row.from, g.Board.board, g.Context) {
+			if move.ToSquare != row.to || move.promotion != row.promo {
+				continue
+			}
+			found = true
+			if got := g.SAN(move); got != row.expected {
+				t.Errorf("got: %s, expected: %s for %s\n", got, row.expected, row.fen)
+			}
+			if got := move.SAN(g.Board, g.Context); got != row.expected {
+				t.Errorf("got: %s, expected: %s for %s\n", got, row.expected, row.fen)
+			}
+		}
+		if !found {
+			t.Errorf("no move %s%s for %s\n", row.from, row.to, row.fen)
+		}
+	}
+}
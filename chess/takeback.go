@@ -0,0 +1,167 @@
+package chess
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrNoUndo            = errors.New("no move to take back")
+	ErrNoRedo            = errors.New("no move taken back to replay")
+	ErrNoTakebackRequest = errors.New("no takeback requested by the opponent")
+)
+
+// snapshot holds what a move changes in a game besides the lists of moves,
+// so that taking the move back restores the game exactly as it was
+type snapshot struct {
+	board     [64]Piece
+	context   Context
+	positions int
+	drawOffer Color
+	bonus     time.Duration // Time the move added to the clock of its player
+}
+
+func (g *Game) snapshot() snapshot {
+	return snapshot{
+		board:     g.Board.board,
+		context:   g.Context,
+		positions: len(g.positions),
+		drawOffer: g.drawOffer,
+	}
+}
+
+// Undo takes back the last move, restoring the board and the Context,
+// including the state of the game, from before it was made. A game ended
+// by a resignation, the clock or the players can't be taken back, a move
+// that ended it can. The clocks
+// are not turned back, only the time the move added to them is taken off.
+func (g *Game) Undo() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.undo()
+}
+
+func (g *Game) undo() error {
+	switch g.Context.State {
+	case Playing, Check:
+	case CheckMate, Draw:
+		if !g.endedByMove() {
+			return &TransitionError{Transition: "undo", State: g.Context.State}
+		}
+	default:
+		return &TransitionError{Transition: "undo", State: g.Context.State}
+	}
+	if len(g.history) == 0 {
+		return ErrNoUndo
+	}
+	if g.outOfTime() {
+		g.stopClock()
+		g.flag()
+		g.publishGameOver()
+		return ErrOutOfTime
+	}
+	if g.clockRunning() {
+		g.stopClock()
+	}
+	ended := g.Context.State == CheckMate || g.Context.State == Draw
+	s := g.history[len(g.history)-1]
+	g.history = g.history[:len(g.history)-1]
+	m := g.Moves[len(g.Moves)-1]
+	g.Moves = g.Moves[:len(g.Moves)-1]
+	if p := g.findPlayer(m.Color); p != nil && len(p.moves) > 0 {
+		p.moves = p.moves[:len(p.moves)-1]
+		p.TimeLeft -= s.bonus
+	}
+
+	g.Board.board = s.board
+	g.Context = s.context
+	g.positions = g.positions[:s.positions]
+	g.drawOffer = s.drawOffer
+	g.takebackRequest = Noone
+	g.redo = append(g.redo, *m)
+	// The clock of the player to move again starts now, also when taking
+	// back a move that ended the game
+	if g.timed {
+		g.turnStartedAt = timeNow()
+	}
+	// The clocks stopped with the move that ended the game
+	if ended && g.stop != nil {
+		g.run()
+	}
+	g.publish(Event{Type: TakebackEvent, Color: m.Color, Move: m, SAN: m.san})
+	return nil
+}
+
+// endedByMove reports whether the game was ended by its last move, rather
+// than by a player or the clock
+func (g *Game) endedByMove() bool {
+	switch g.Context.Termination {
+	case Checkmated, Stalemate, FivefoldRepetition, SeventyFiveMoveRule, InsufficientMaterial:
+		return true
+	}
+	return false
+}
+
+// Redo plays the last move taken back by Undo again, as long as no other
+// move was made since
+func (g *Game) Redo() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Context.State != Playing && g.Context.State != Check {
+		return &TransitionError{Transition: "redo", State: g.Context.State}
+	}
+	if len(g.redo) == 0 {
+		return ErrNoRedo
+	}
+	m := g.redo[len(g.redo)-1]
+	redo := g.redo[:len(g.redo)-1]
+	if err := g.move(m.FromSquare, m.ToSquare, pieceKind(m.promotion)); err != nil {
+		return err
+	}
+	g.redo = redo
+	return nil
+}
+
+// HandleTakebackRequest asks the opponent to take back the last move of
+// the player, and the reply to it if the opponent has made one
+func (g *Game) HandleTakebackRequest(uid string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Context.State != Playing && g.Context.State != Check {
+		return ErrNotPlaying
+	}
+	p := g.getPlayerByID(uid)
+	if p == nil {
+		return ErrNotInGame
+	}
+	if len(p.moves) == 0 {
+		return ErrNoUndo
+	}
+	g.takebackRequest = p.Color
+	g.publishPlayer(TakebackRequestEvent, p)
+	return nil
+}
+
+// HandleTakebackAccept accepts a takeback requested by the opponent, the
+// opponent is to move afterwards
+func (g *Game) HandleTakebackAccept(uid string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Context.State != Playing && g.Context.State != Check {
+		return ErrNotPlaying
+	}
+	p := g.getPlayerByID(uid)
+	if p == nil {
+		return ErrNotInGame
+	}
+	requester := p.Color.opponent()
+	if g.takebackRequest != requester {
+		return ErrNoTakebackRequest
+	}
+	if g.Context.ColorsTurn == requester {
+		if err := g.undo(); err != nil {
+			return err
+		}
+	}
+	return g.undo()
+}
@@ -0,0 +1,167 @@
+package chess
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUndoRedo(t *testing.T) {
+	table := []struct {
+		fen   string
+		moves []string
+	}{
+		// Castling rights and en passant
+		{"r3k2r/pppp1ppp/8/8/4P3/8/PPP2PPP/R3K2R b KQkq - 0 1", []string{"d7d5", "e4d5", "e8g8", "e1c1"}},
+		// Promotion with capture
+		{"1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1", []string{"a7b8r", "e8d7", "b8b7"}},
+		// Fool's mate ends the game
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", []string{"f2f3", "e7e5", "g2g4", "d8h4"}},
+	}
+
+	for _, row := range table {
+		g := NewGameFromFEN(row.fen)
+		g.Context.State = Playing
+		g.Players = []*Player{{Color: White}, {Color: Black}}
+
+		var fens []string
+		var contexts []Context
+		for _, m := range row.moves {
+			fens = append(fens, g.FenString())
+			contexts = append(contexts, g.Context)
+			assert.Nil(t, g.Move(m), m)
+		}
+		final, finalContext := g.FenString(), g.Context
+
+		for i := len(row.moves) - 1; i >= 0; i-- {
+			assert.Nil(t, g.Undo())
+			assert.Equal(t, fens[i], g.FenString(), row.moves[i])
+			assert.Equal(t, contexts[i], g.Context, row.moves[i])
+			assert.Len(t, g.Moves, i)
+		}
+		assert.Equal(t, ErrNoUndo, g.Undo())
+		assert.Len(t, g.positions, 0)
+
+		for range row.moves {
+			assert.Nil(t, g.Redo())
+		}
+		if err := g.Redo(); g.Context.State == CheckMate {
+			var transitionErr *TransitionError
+			assert.True(t, errors.As(err, &transitionErr), err)
+		} else {
+			assert.Equal(t, ErrNoRedo, err)
+		}
+		assert.Equal(t, final, g.FenString())
+		assert.Equal(t, finalContext, g.Context)
+		assert.Len(t, g.Moves, len(row.moves))
+		for i, m := range g.Moves {
+			assert.Equal(t, row.moves[i], m.UCI())
+		}
+	}
+}
+
+func TestUndoClearsRedo(t *testing.T) {
+	g := NewGame()
+	g.Context.State = Playing
+	g.Players = []*Player{{Color: White}, {Color: Black}}
+
+	assert.Nil(t, g.Move("e2e4"))
+	assert.Nil(t, g.Move("e7e5"))
+	assert.Nil(t, g.Undo())
+	assert.Nil(t, g.Undo())
+	assert.Nil(t, g.Redo())
+
+	// A different move starts a new line, the move taken back is lost
+	assert.Nil(t, g.Move("c7c5"))
+	assert.Equal(t, ErrNoRedo, g.Redo())
+	assert.Equal(t, "rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", g.FenString())
+	assert.Len(t, g.getPlayer(White).moves, 1)
+	assert.Len(t, g.getPlayer(Black).moves, 1)
+}
+
+func TestUndoRedoFinished(t *testing.T) {
+	newGame := func() *Game {
+		g := NewGame()
+		g.Context.State = Playing
+		g.Players = []*Player{{Color: White, ID: "white"}, {Color: Black, ID: "black"}}
+		assert.Nil(t, g.Move("e2e4"))
+		assert.Nil(t, g.Move("e7e5"))
+		return g
+	}
+	var transitionErr *TransitionError
+
+	// A move taken back can't be replayed once the game is over
+	g := newGame()
+	assert.Nil(t, g.Undo())
+	assert.Nil(t, g.HandleResign("white"))
+	assert.True(t, errors.As(g.Redo(), &transitionErr))
+	assert.Equal(t, Over, g.Context.State)
+	assert.Len(t, g.Moves, 1)
+
+	// Nor is a resignation, a draw agreed or a loss on time taken back
+	g = newGame()
+	assert.Nil(t, g.HandleResign("white"))
+	assert.True(t, errors.As(g.Undo(), &transitionErr))
+	assert.Equal(t, BlackWon, g.Context.Result())
+	assert.Equal(t, Resignation, g.Context.Termination)
+	assert.Len(t, g.Moves, 2)
+
+	g = newGame()
+	g.Context.State, g.Context.Termination = Draw, Agreement
+	assert.True(t, errors.As(g.Undo(), &transitionErr))
+	g.Context.State, g.Context.Termination = Over, Timeout
+	assert.True(t, errors.As(g.Undo(), &transitionErr))
+
+	// A move that ended the game is
+	g = newGame()
+	for _, m := range []string{"d1h5", "b8c6", "f1c4", "g8f6", "h5f7"} {
+		assert.Nil(t, g.Move(m))
+	}
+	assert.Equal(t, CheckMate, g.Context.State)
+	assert.Nil(t, g.Undo())
+	assert.Equal(t, Playing, g.Context.State)
+	assert.Nil(t, g.Context.WinningPlayer)
+}
+
+func TestTakeback(t *testing.T) {
+	g := NewGame()
+	g.Context.State = Playing
+	g.Players = []*Player{
+		{Color: White, ID: "white"},
+		{Color: Black, ID: "black"},
+	}
+	start := g.FenString()
+
+	assert.Equal(t, ErrNoUndo, g.HandleTakebackRequest("white"))
+	assert.Equal(t, ErrNotInGame, g.HandleTakebackRequest("nobody"))
+	assert.Equal(t, ErrNoTakebackRequest, g.HandleTakebackAccept("black"))
+
+	// Before the opponent replies only the requester's move is taken back
+	assert.Nil(t, g.Move("e2e4"))
+	assert.Nil(t, g.HandleTakebackRequest("white"))
+	assert.Equal(t, ErrNoTakebackRequest, g.HandleTakebackAccept("white"))
+	assert.Nil(t, g.HandleTakebackAccept("black"))
+	assert.Equal(t, start, g.FenString())
+	assert.Equal(t, White, g.Context.ColorsTurn)
+
+	// After it, the reply is taken back as well
+	assert.Nil(t, g.Move("d2d4"))
+	assert.Nil(t, g.Move("d7d5"))
+	afterD4 := g.FenString()
+	assert.Nil(t, g.Move("c2c4"))
+	assert.Nil(t, g.Move("e7e5"))
+	assert.Nil(t, g.HandleTakebackRequest("white"))
+	assert.Nil(t, g.HandleTakebackAccept("black"))
+	assert.Equal(t, afterD4, g.FenString())
+	assert.Len(t, g.Moves, 2)
+
+	// The request is declined by a move of the opponent
+	assert.Nil(t, g.Move("c2c4"))
+	assert.Nil(t, g.HandleTakebackRequest("white"))
+	assert.Nil(t, g.Move("e7e5"))
+	assert.Equal(t, ErrNoTakebackRequest, g.HandleTakebackAccept("black"))
+
+	g.Context.State = CheckMate
+	assert.Equal(t, ErrNotPlaying, g.HandleTakebackRequest("white"))
+}
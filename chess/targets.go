@@ -142,3 +142,61 @@ func generalTargets(s Square, board [64]Piece) []Move {
 	}
 	return targets
 }
+
+// isAttacked reports if any piece of player p attacks square s. It walks
+// out from s instead of generating the moves of every piece of p, as it
+// is called for every move tried when looking for legal moves.
+func isAttacked(s Square, p Color, b [64]Piece) bool {
+	var pawn, knight, bishop, rook, queen, king Piece
+	var pawnRow int
+	switch p {
+	case White:
+		pawn, knight, bishop, rook, queen, king = WhitePawn, WhiteKnight, WhiteBishop, WhiteRook, WhiteQueen, WhiteKing
+		pawnRow = -1
+	case Black:
+		pawn, knight, bishop, rook, queen, king = BlackPawn, BlackKnight, BlackBishop, BlackRook, BlackQueen, BlackKing
+		pawnRow = 1
+	default:
+		return false
+	}
+
+	row, col := int(s.row()), int(s.col())
+	pieceAt := func(r, c int) Piece {
+		if r < 0 || r > 7 || c < 0 || c > 7 {
+			return Empty
+		}
+		return b[r*8+c]
+	}
+
+	// Pawns attack diagonally forward, so look diagonally backwards
+	if pieceAt(row+pawnRow, col-1) == pawn || pieceAt(row+pawnRow, col+1) == pawn {
+		return true
+	}
+
+	for _, d := range [8][2]int{{2, -1}, {2, 1}, {1, 2}, {-1, 2}, {-2, 1}, {-2, -1}, {-1, -2}, {1, -2}} {
+		if pieceAt(row+d[0], col+d[1]) == knight {
+			return true
+		}
+	}
+
+	for _, d := range [8][2]int{{1, -1}, {1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}} {
+		if pieceAt(row+d[0], col+d[1]) == king {
+			return true
+		}
+	}
+
+	for _, d := range [8][2]int{{1, -1}, {1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}} {
+		diagonal := d[0] != 0 && d[1] != 0
+		for r, c := row+d[0], col+d[1]; r >= 0 && r <= 7 && c >= 0 && c <= 7; r, c = r+d[0], c+d[1] {
+			piece := b[r*8+c]
+			if piece == Empty {
+				continue
+			}
+			if piece == queen || (diagonal && piece == bishop) || (!diagonal && piece == rook) {
+				return true
+			}
+			break
+		}
+	}
+	return false
+}
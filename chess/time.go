@@ -1,7 +1,75 @@
 package chess
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
-func timeNow() int64 {
-	return time.Now().UTC().UnixNano()
+var ErrOutOfTime = errors.New("player ran out of time")
+
+var processStart = time.Now()
+
+// timeNow returns the nanoseconds since the process started, read from the
+// monotonic clock so that setting the system time doesn't move the game
+// clocks. Tests replace it with a fake clock.
+var timeNow = func() int64 {
+	return int64(time.Since(processStart))
+}
+
+// The clock of the side to move runs from turnStartedAt, TimeLeft of its
+// player is the time it had left when its turn started, and turnSpent the
+// time spent on the turn before the game was last paused. The delay and the
+// bonus of a turn are applied once, to its whole time, when it ends. The
+// clocks of an untimed game never run.
+
+// clockRunning reports if the clock of the side to move is running
+func (g *Game) clockRunning() bool {
+	return g.timed && (g.Context.State == Playing || g.Context.State == Check)
+}
+
+// timeLeft returns the time left to the player, counting the time spent on
+// the current turn if it is the player's turn
+func (g *Game) timeLeft(p *Player) time.Duration {
+	if p.Color != g.Context.ColorsTurn || !g.timed {
+		return p.TimeLeft
+	}
+	return p.TimeLeft - g.timeControl(p.Color).spent(len(p.moves)+1, g.turnTime())
+}
+
+// turnTime returns the time spent on the current turn, pauses left out
+func (g *Game) turnTime() time.Duration {
+	if !g.clockRunning() {
+		return g.turnSpent
+	}
+	return g.turnSpent + time.Duration(timeNow()-g.turnStartedAt)
+}
+
+// outOfTime reports if the flag of the side to move has fallen
+func (g *Game) outOfTime() bool {
+	return g.clockRunning() && g.timeLeft(g.getPlayer(g.Context.ColorsTurn)) <= 0
+}
+
+// stopClock charges the side to move for the time spent on its turn, which
+// is returned, and starts the turn anew
+func (g *Game) stopClock() time.Duration {
+	p := g.getPlayer(g.Context.ColorsTurn)
+	elapsed := g.turnTime()
+	p.TimeLeft -= g.timeControl(p.Color).spent(len(p.moves)+1, elapsed)
+	g.turnSpent = 0
+	g.turnStartedAt = timeNow()
+	return elapsed
+}
+
+// flag ends the game in favour of the opponent of the side to move, who ran
+// out of time, or in a draw if the opponent can't checkmate
+func (g *Game) flag() {
+	opp := g.getPlayer(g.Context.ColorsTurn.opponent())
+	g.Context.Termination = Timeout
+	g.halt()
+	if !canCheckmate(opp.Color, g.Board.board) {
+		g.Context.State = Draw
+		return
+	}
+	g.Context.WinningPlayer = opp
+	g.Context.State = Over
 }
@@ -0,0 +1,131 @@
+package chess
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock replaces timeNow with a clock that only moves when advanced
+type fakeClock struct {
+	mu      sync.Mutex
+	now     int64
+	realNow func() int64
+}
+
+func useFakeClock() *fakeClock {
+	c := &fakeClock{
+		now:     time.Date(2020, 4, 2, 10, 0, 0, 0, time.UTC).UnixNano(),
+		realNow: timeNow,
+	}
+	timeNow = c.time
+	return c
+}
+
+func (c *fakeClock) time() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now += int64(d)
+}
+
+func (c *fakeClock) restore() {
+	timeNow = c.realNow
+}
+
+func TestClock(t *testing.T) {
+	clock := useFakeClock()
+	defer clock.restore()
+
+	g := NewGame()
+	g.Players = []*Player{
+		{Color: White, ID: "white"},
+		{Color: Black, ID: "black"},
+	}
+	g.StartingTime = time.Minute
+	cleanup := g.Start()
+	defer cleanup()
+
+	// Only the clock of the side to move runs
+	clock.advance(10 * time.Second)
+	assert.Equal(t, 50*time.Second, g.TimeLeft(White))
+	assert.Equal(t, time.Minute, g.TimeLeft(Black))
+	assert.Nil(t, g.Move("e2e4"))
+	assert.Equal(t, 50*time.Second, g.TimeLeft(White))
+
+	clock.advance(20 * time.Second)
+	assert.Nil(t, g.Move("e7e5"))
+	clock.advance(5 * time.Second)
+	assert.Equal(t, 45*time.Second, g.TimeLeft(White))
+	assert.Equal(t, 40*time.Second, g.TimeLeft(Black))
+
+	// Taking back a move doesn't give back the time spent
+	assert.Nil(t, g.Undo())
+	assert.Equal(t, 45*time.Second, g.TimeLeft(White))
+	assert.Equal(t, 40*time.Second, g.TimeLeft(Black))
+	clock.advance(10 * time.Second)
+	assert.Equal(t, 30*time.Second, g.TimeLeft(Black))
+
+	// A move made after the flag fell loses on time
+	clock.advance(30 * time.Second)
+	assert.Equal(t, ErrOutOfTime, g.Move("e7e5"))
+	ctx := g.CurrentContext()
+	assert.Equal(t, Over, ctx.State)
+	assert.Equal(t, Timeout, ctx.Termination)
+	assert.Equal(t, WhiteWon, ctx.Result())
+	assert.Equal(t, time.Duration(0), g.TimeLeft(Black))
+
+	// The clocks stop when the game is over
+	clock.advance(time.Minute)
+	assert.Equal(t, 45*time.Second, g.TimeLeft(White))
+	assert.Equal(t, time.Duration(0), g.TimeLeft(Black))
+	assert.Len(t, g.Moves, 1)
+}
+
+func TestUntimed(t *testing.T) {
+	clock := useFakeClock()
+	defer clock.restore()
+	g := NewGame()
+	g.Players = []*Player{{Color: White}, {Color: Black}}
+	cleanup := g.Start()
+	defer cleanup()
+
+	// Without a time control or a starting time no flag falls
+	clock.advance(time.Hour)
+	assert.Nil(t, g.Move("e2e4"))
+	assert.Equal(t, time.Duration(0), g.TimeLeft(White))
+	assert.Equal(t, Playing, g.CurrentContext().State)
+}
+
+func TestTimeLeftNoPlayer(t *testing.T) {
+	g := NewGame()
+	g.StartingTime = time.Minute
+	assert.Equal(t, time.Duration(0), g.TimeLeft(White))
+
+	// Before a player has picked a color, or for no color at all
+	assert.Nil(t, g.HandlePick("white", "white"))
+	assert.Equal(t, time.Duration(0), g.TimeLeft(Black))
+	assert.Equal(t, time.Duration(0), g.TimeLeft(Noone))
+}
+
+func TestClockMonotonic(t *testing.T) {
+	before := timeNow()
+	time.Sleep(time.Millisecond)
+	assert.True(t, timeNow()-before >= int64(time.Millisecond))
+
+	// The Date tag comes from the wall clock, not from the game clocks
+	clock := useFakeClock()
+	defer clock.restore()
+	g := NewGame()
+	cleanup := g.Start()
+	defer cleanup()
+	date, _ := g.PGN().Tag("Date")
+	assert.Equal(t, time.Now().UTC().Format("2006.01.02"), date)
+}
@@ -0,0 +1,185 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTimeControl = errors.New("invalid time control")
+	// ErrSandclock is returned for the sandclock form of the PGN tag, "*180",
+	// which is valid but not supported
+	ErrSandclock = fmt.Errorf("%w: sandclock not supported", ErrTimeControl)
+)
+
+// DelayMode tells how the delay of a period is applied
+type DelayMode byte
+
+const (
+	// SimpleDelay, or US delay, waits for the delay before the clock
+	// of the player to move starts running
+	SimpleDelay DelayMode = iota
+	// BronsteinDelay gives back the time spent on a move, up to the
+	// delay, after the move is made
+	BronsteinDelay
+)
+
+// Period is a part of a time control, Moves moves have to be made in
+// Time, the last period is repeated
+type Period struct {
+	Moves     int           // Moves to make in the period, 0 for the rest of the game
+	Time      time.Duration // Time added to the clock when the period starts
+	Increment time.Duration // Fischer increment, added after each move
+	Delay     time.Duration
+	DelayMode DelayMode
+}
+
+// TimeControl is the sequence of periods a player's clock runs through,
+// a time control without periods is untimed
+type TimeControl struct {
+	Periods []Period
+}
+
+// NewTimeControl returns a time control with a single period of t for the
+// whole game, with increment added after each move
+func NewTimeControl(t, increment time.Duration) TimeControl {
+	return TimeControl{Periods: []Period{{Time: t, Increment: increment}}}
+}
+
+// Periods are written as in the PGN TimeControl tag, "40/7200", "300+2",
+// extended with "d" for a simple delay and "b" for a Bronstein delay,
+// "300d5", all in seconds. Numbers have at most 9 digits so that no
+// duration overflows.
+var periodRegexp = regexp.MustCompile(`^(?:(\d{1,9})/)?(\d{1,9})(?:\+(\d{1,9}))?(?:([db])(\d{1,9}))?$`)
+
+// ParseTimeControl parses the value of a PGN TimeControl tag, periods
+// separated by ":", such as "40/7200:3600+30", where every period but the
+// last has a number of moves. "-" and "?" give an untimed time control.
+// Sandclock time controls, "*180", are not supported and return
+// ErrSandclock.
+func ParseTimeControl(s string) (TimeControl, error) {
+	var tc TimeControl
+	if s == "-" || s == "?" {
+		return tc, nil
+	}
+	fields := strings.Split(s, ":")
+	for i, field := range fields {
+		if strings.HasPrefix(field, "*") {
+			return TimeControl{}, fmt.Errorf("%w: %q", ErrSandclock, s)
+		}
+		groups := periodRegexp.FindStringSubmatch(field)
+		if groups == nil {
+			return TimeControl{}, fmt.Errorf("%w: %q", ErrTimeControl, s)
+		}
+		var p Period
+		p.Moves, _ = strconv.Atoi(groups[1])
+		p.Time = seconds(groups[2])
+		p.Increment = seconds(groups[3])
+		p.Delay = seconds(groups[5])
+		if groups[4] == "b" {
+			p.DelayMode = BronsteinDelay
+		}
+		// Only the last period can last until the end of the game
+		if groups[1] == "" && i < len(fields)-1 {
+			return TimeControl{}, fmt.Errorf("%w: %q", ErrTimeControl, s)
+		}
+		if groups[1] != "" && p.Moves == 0 {
+			return TimeControl{}, fmt.Errorf("%w: %q", ErrTimeControl, s)
+		}
+		tc.Periods = append(tc.Periods, p)
+	}
+	return tc, nil
+}
+
+func seconds(s string) time.Duration {
+	n, _ := strconv.Atoi(s)
+	return time.Duration(n) * time.Second
+}
+
+// String returns the time control as the value of a PGN TimeControl tag
+func (tc TimeControl) String() string {
+	if len(tc.Periods) == 0 {
+		return "-"
+	}
+	var periods []string
+	for _, p := range tc.Periods {
+		var s string
+		if p.Moves > 0 {
+			s += fmt.Sprintf("%d/", p.Moves)
+		}
+		s += fmt.Sprintf("%d", int64(p.Time/time.Second))
+		if p.Increment > 0 {
+			s += fmt.Sprintf("+%d", int64(p.Increment/time.Second))
+		}
+		if p.Delay > 0 {
+			mode := "d"
+			if p.DelayMode == BronsteinDelay {
+				mode = "b"
+			}
+			s += fmt.Sprintf("%s%d", mode, int64(p.Delay/time.Second))
+		}
+		periods = append(periods, s)
+	}
+	return strings.Join(periods, ":")
+}
+
+// period returns the index of the period the nth move of a player is made
+// in, counting from one, and whether the move is the last of its period
+func (tc TimeControl) period(n int) (int, bool) {
+	i, start := 0, 0
+	for {
+		p := tc.Periods[i]
+		if p.Moves == 0 {
+			return i, false
+		}
+		if n <= start+p.Moves {
+			return i, n == start+p.Moves
+		}
+		start += p.Moves
+		if i < len(tc.Periods)-1 {
+			i++
+		}
+	}
+}
+
+// spent returns the time charged for the nth move of a player after
+// elapsed time, a simple delay is not charged
+func (tc TimeControl) spent(n int, elapsed time.Duration) time.Duration {
+	i, _ := tc.period(n)
+	p := tc.Periods[i]
+	if p.DelayMode == SimpleDelay {
+		elapsed -= p.Delay
+		if elapsed < 0 {
+			return 0
+		}
+	}
+	return elapsed
+}
+
+// bonus returns the time added to the clock of a player after making the
+// nth move in elapsed time: the increment, the Bronstein delay, and the
+// time of the next period when the move ends one
+func (tc TimeControl) bonus(n int, elapsed time.Duration) time.Duration {
+	i, last := tc.period(n)
+	p := tc.Periods[i]
+	bonus := p.Increment
+	if p.DelayMode == BronsteinDelay {
+		if elapsed < p.Delay {
+			bonus += elapsed
+		} else {
+			bonus += p.Delay
+		}
+	}
+	if last {
+		next := i + 1
+		if next == len(tc.Periods) {
+			next = i
+		}
+		bonus += tc.Periods[next].Time
+	}
+	return bonus
+}
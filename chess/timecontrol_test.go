@@ -0,0 +1,261 @@
+package chess
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTimeControl(t *testing.T) {
+	table := []struct {
+		tag      string
+		expected TimeControl
+		written  string
+	}{
+		{"300", TimeControl{[]Period{{Time: 300 * time.Second}}}, "300"},
+		{"180+2", TimeControl{[]Period{{Time: 180 * time.Second, Increment: 2 * time.Second}}}, "180+2"},
+		{"40/7200:3600+30", TimeControl{[]Period{
+			{Moves: 40, Time: 7200 * time.Second},
+			{Time: 3600 * time.Second, Increment: 30 * time.Second},
+		}}, "40/7200:3600+30"},
+		{"40/5400+30:1800+30", TimeControl{[]Period{
+			{Moves: 40, Time: 5400 * time.Second, Increment: 30 * time.Second},
+			{Time: 1800 * time.Second, Increment: 30 * time.Second},
+		}}, "40/5400+30:1800+30"},
+		{"40/7200", TimeControl{[]Period{{Moves: 40, Time: 7200 * time.Second}}}, "40/7200"},
+		{"5400d5", TimeControl{[]Period{{Time: 5400 * time.Second, Delay: 5 * time.Second}}}, "5400d5"},
+		{"300b3", TimeControl{[]Period{{Time: 300 * time.Second, Delay: 3 * time.Second, DelayMode: BronsteinDelay}}}, "300b3"},
+		{"-", TimeControl{}, "-"},
+		{"?", TimeControl{}, "-"},
+	}
+	for _, row := range table {
+		got, err := ParseTimeControl(row.tag)
+		assert.Nil(t, err, row.tag)
+		assert.Equal(t, row.expected, got, row.tag)
+		assert.Equal(t, row.written, got.String(), row.tag)
+	}
+
+	for _, tag := range []string{"", "*180", "5m", "3600:40/7200", "0/300", "300+", "40/"} {
+		_, err := ParseTimeControl(tag)
+		assert.True(t, errors.Is(err, ErrTimeControl), tag)
+	}
+}
+
+func TestParseTimeControlPeriods(t *testing.T) {
+	table := []struct {
+		tag     string
+		periods int
+		err     error
+	}{
+		{"40/7200:20/3600:900+30", 3, nil},
+		// A last period with a number of moves is repeated
+		{"40/7200:20/3600", 2, nil},
+		{"40/7200:3600", 2, nil},
+		// Every period but the last needs a number of moves
+		{"40/7200:3600:900", 0, ErrTimeControl},
+		{"7200:40/3600", 0, ErrTimeControl},
+		{"40/7200:0/3600", 0, ErrTimeControl},
+		// Empty periods
+		{"40/7200:", 0, ErrTimeControl},
+		{":3600", 0, ErrTimeControl},
+		{"40/7200::3600", 0, ErrTimeControl},
+		// Numbers too large for a duration
+		{"40/99999999999", 0, ErrTimeControl},
+		{"9999999999/7200:3600", 0, ErrTimeControl},
+		// Sandclock is valid in the tag but not supported
+		{"*180", 0, ErrSandclock},
+		{"40/7200:*60", 0, ErrSandclock},
+	}
+	for _, row := range table {
+		got, err := ParseTimeControl(row.tag)
+		if row.err != nil {
+			assert.True(t, errors.Is(err, row.err), "%s: %v", row.tag, err)
+			continue
+		}
+		assert.Nil(t, err, row.tag)
+		assert.Len(t, got.Periods, row.periods, row.tag)
+		assert.Equal(t, row.tag, got.String())
+	}
+	assert.True(t, errors.Is(ErrSandclock, ErrTimeControl))
+}
+
+func TestTimeControlClock(t *testing.T) {
+	// Knights going back and forth, a move of each player per row
+	moves := []string{"g1f3", "g8f6", "f3g1", "f6g8"}
+	type turn struct {
+		think        time.Duration // Time spent on the move
+		white, black time.Duration // Clocks after the move
+	}
+	table := []struct {
+		name         string
+		white, black string
+		turns        []turn
+	}{
+		{
+			name:  "increment",
+			white: "60+2", black: "60+2",
+			turns: []turn{
+				{10 * time.Second, 52 * time.Second, time.Minute},
+				{20 * time.Second, 52 * time.Second, 42 * time.Second},
+			},
+		},
+		{
+			name:  "simple delay",
+			white: "60d5", black: "60d5",
+			turns: []turn{
+				{3 * time.Second, time.Minute, time.Minute},
+				{8 * time.Second, time.Minute, 57 * time.Second},
+			},
+		},
+		{
+			name:  "bronstein delay",
+			white: "60b5", black: "60b5",
+			turns: []turn{
+				{3 * time.Second, time.Minute, time.Minute},
+				{8 * time.Second, time.Minute, 57 * time.Second},
+			},
+		},
+		{
+			name:  "periods",
+			white: "2/60:30+1", black: "2/60:30+1",
+			turns: []turn{
+				{10 * time.Second, 50 * time.Second, time.Minute},
+				{10 * time.Second, 50 * time.Second, 50 * time.Second},
+				{10 * time.Second, 70 * time.Second, 50 * time.Second},
+				{10 * time.Second, 70 * time.Second, 70 * time.Second},
+				{10 * time.Second, 61 * time.Second, 70 * time.Second},
+			},
+		},
+		{
+			name:  "repeated period",
+			white: "1/60", black: "1/60",
+			turns: []turn{
+				{10 * time.Second, 110 * time.Second, time.Minute},
+				{10 * time.Second, 110 * time.Second, 110 * time.Second},
+				{10 * time.Second, 160 * time.Second, 110 * time.Second},
+			},
+		},
+		{
+			name:  "odds",
+			white: "300", black: "240",
+			turns: []turn{
+				{10 * time.Second, 290 * time.Second, 240 * time.Second},
+				{10 * time.Second, 290 * time.Second, 230 * time.Second},
+			},
+		},
+	}
+
+	for _, row := range table {
+		t.Run(row.name, func(t *testing.T) {
+			clock := useFakeClock()
+			defer clock.restore()
+
+			white, err := ParseTimeControl(row.white)
+			assert.Nil(t, err)
+			black, err := ParseTimeControl(row.black)
+			assert.Nil(t, err)
+			g := NewGame()
+			g.Players = []*Player{{Color: White}, {Color: Black}}
+			assert.Nil(t, g.HandleSetTimeControl(white, black))
+			cleanup := g.Start()
+			defer cleanup()
+
+			for i, turn := range row.turns {
+				clock.advance(turn.think)
+				assert.Nil(t, g.Move(moves[i%len(moves)]))
+				assert.Equal(t, turn.white, g.TimeLeft(White), "white after move %d", i+1)
+				assert.Equal(t, turn.black, g.TimeLeft(Black), "black after move %d", i+1)
+			}
+		})
+	}
+}
+
+func TestTimeControlFlag(t *testing.T) {
+	table := []struct {
+		tc    string
+		think time.Duration // Time white still has at the limit
+	}{
+		{"60", time.Minute},
+		{"60+5", time.Minute},
+		// A simple delay runs before the clock, a Bronstein delay is only
+		// given back once the move is made
+		{"60d5", 65 * time.Second},
+		{"60b5", time.Minute},
+	}
+	for _, row := range table {
+		for _, extra := range []time.Duration{-time.Millisecond, 0} {
+			clock := useFakeClock()
+			tc, _ := ParseTimeControl(row.tc)
+			g := NewGame()
+			g.Players = []*Player{{Color: White}, {Color: Black}}
+			assert.Nil(t, g.HandleSetTimeControl(tc, tc))
+			cleanup := g.Start()
+
+			clock.advance(row.think + extra)
+			err := g.Move("e2e4")
+			if extra < 0 {
+				assert.Nil(t, err, row.tc)
+			} else {
+				assert.Equal(t, ErrOutOfTime, err, row.tc)
+				ctx := g.CurrentContext()
+				assert.Equal(t, BlackWon, ctx.Result(), row.tc)
+			}
+			cleanup()
+			clock.restore()
+		}
+	}
+}
+
+func TestUndoTimeControl(t *testing.T) {
+	clock := useFakeClock()
+	defer clock.restore()
+
+	g := NewGame()
+	g.Players = []*Player{{Color: White}, {Color: Black}}
+	assert.Nil(t, g.HandleSetTimeControl(NewTimeControl(time.Minute, 10*time.Second), NewTimeControl(time.Minute, 10*time.Second)))
+	cleanup := g.Start()
+	defer cleanup()
+
+	clock.advance(5 * time.Second)
+	assert.Nil(t, g.Move("e2e4"))
+	assert.Equal(t, 65*time.Second, g.TimeLeft(White))
+
+	// The increment is taken back with the move, the time spent is not
+	clock.advance(2 * time.Second)
+	assert.Nil(t, g.Undo())
+	assert.Equal(t, 55*time.Second, g.TimeLeft(White))
+	assert.Equal(t, 58*time.Second, g.TimeLeft(Black))
+}
+
+func TestTimeControlPGN(t *testing.T) {
+	g := NewGame()
+	tc, _ := ParseTimeControl("40/7200:3600+30")
+	assert.Nil(t, g.HandleSetTimeControl(tc, tc))
+	var b strings.Builder
+	assert.Nil(t, g.WritePGN(&b))
+	assert.Contains(t, b.String(), `[TimeControl "40/7200:3600+30"]`)
+
+	// Time odds are not written
+	assert.Nil(t, g.HandleSetTimeControl(NewTimeControl(5*time.Minute, 0), NewTimeControl(4*time.Minute, 0)))
+	b.Reset()
+	assert.Nil(t, g.WritePGN(&b))
+	assert.NotContains(t, b.String(), "TimeControl")
+
+	pgn, err := ParsePGN(strings.NewReader("[TimeControl \"180+2\"]\n\n1. e4 *\n"))
+	assert.Nil(t, err)
+	g, err = pgn.Game()
+	assert.Nil(t, err)
+	assert.Equal(t, NewTimeControl(3*time.Minute, 2*time.Second), g.TimeControl(Black))
+
+	// The clocks of the game read run once it is started
+	clock := useFakeClock()
+	defer clock.restore()
+	cleanup := g.Start()
+	defer cleanup()
+	clock.advance(5 * time.Second)
+	assert.Equal(t, 175*time.Second, g.TimeLeft(Black))
+	assert.Equal(t, 3*time.Minute, g.TimeLeft(White))
+}
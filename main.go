@@ -134,7 +134,11 @@ func main() {
 	//}
 
 	if fenString != "" {
-		b = chess.NewGameFromFEN(fenString)
+		b, err = chess.ParseFEN(fenString)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 		fmt.Println(pretty(b.Board.BoardMap()))
 		os.Exit(0)
 	}